@@ -0,0 +1,108 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmdName = "cache"
+
+// cacheCmd groups the commands working on the API response cache
+var cacheCmd = &cobra.Command{
+	Use:   cacheCmdName,
+	Short: cacheCmdName + " clear|stats",
+	Long:  cacheCmdName + ` clear|stats: API responses are cached in --cache-dir and sent again with If-None-Match/If-Modified-Since. github does not count the 304 Not Modified answers against the rate limit, so re-running a report on an unchanged repo is nearly free`,
+}
+
+// cacheClearCmd removes every cached response
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "clear",
+	Long:  `clear: removes every cached API response`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := getFlagString(cmd, "cache-dir")
+		removed, err := github.NewCache(dir).Clear()
+		if err != nil {
+			fmt.Println("an error occurred while clearing the cache. err:", err)
+			return
+		}
+		fmt.Printf("removed %d cached responses from %s\n", removed, dir)
+	},
+}
+
+// cacheStatsCmd prints how many responses are cached and their size
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "stats",
+	Long:  `stats: prints the number of cached API responses, their size on disk and when the oldest and newest were stored`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		stats, err := github.NewCache(getFlagString(cmd, "cache-dir")).Stats()
+		if err != nil {
+			fmt.Println("an error occurred while reading the cache. err:", err)
+			return
+		}
+		out := newRecordWriter(os.Stdout, format, []string{"dir", "entries", "bytes", "oldest", "newest"})
+		var oldest, newest string
+		if stats.Entries > 0 {
+			oldest, newest = stats.Oldest.Format("2006-01-02 15:04:05"), stats.Newest.Format("2006-01-02 15:04:05")
+		}
+		out.write(stats, []string{stats.Dir, fmt.Sprint(stats.Entries), fmt.Sprint(stats.Bytes), oldest, newest})
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+	},
+}
+
+// defaultCacheDir is github-teamwork in the user cache directory, e.g. ~/.cache/github-teamwork
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "github-teamwork")
+}
+
+// getCacheDir returns the cache directory, or "" with --no-cache
+func getCacheDir(cmd *cobra.Command) string {
+	if getFlagBool(cmd, "no-cache") {
+		return ""
+	}
+	return getFlagString(cmd, "cache-dir")
+}
+
+func init() {
+	RootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	addOutputFlags(cacheStatsCmd)
+}
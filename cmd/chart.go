@@ -0,0 +1,324 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/util"
+)
+
+// chart types
+const (
+	chartTypeLine       = "line"
+	chartTypeStackedBar = "stacked-bar"
+	chartTypeArea       = "area"
+)
+
+// chart formats
+const (
+	chartFormatPNG  = "png"
+	chartFormatSVG  = "svg"
+	chartFormatHTML = "html"
+)
+
+// chartOptions are the chart flags shared by every command that draws a chart
+type chartOptions struct {
+	Bucket string
+	Type   string
+	Format string
+	// Command and Params record how the chart was asked for, for the html report
+	Command string
+	Params  []chartParam
+}
+
+type chartParam struct {
+	Name  string
+	Value string
+}
+
+// chartSpec describes a chart of any number of named series over the same buckets
+type chartSpec struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Type   string
+	Series []*bucketSeries
+}
+
+// redactedParams are flags whose values are credentials, or may carry them, and stay out of the
+// shareable html report
+var redactedParams = map[string]bool{
+	"token":           true,
+	"proxy":           true,
+	"app-id":          true,
+	"app-key":         true,
+	"installation-id": true,
+	"app-token-url":   true,
+}
+
+func addChartFlags(cmd *cobra.Command) {
+	cmd.Flags().String("bucket", bucketDay, "chart bucket: day, week (ISO, starting monday) or month")
+	cmd.Flags().String("chart-type", chartTypeLine, "chart type: line, stacked-bar or area")
+	cmd.Flags().String("chart-format", chartFormatPNG, "chart format: png, svg or html (one self-contained file with the svg chart, its data and the run parameters)")
+}
+
+func getChartOptions(cmd *cobra.Command) (chartOptions, error) {
+	var opts chartOptions
+	opts.Bucket = getFlagString(cmd, "bucket")
+	switch opts.Bucket {
+	case bucketDay, bucketWeek, bucketMonth:
+	default:
+		return opts, errors.New("bucket needs to be day, week or month")
+	}
+	opts.Type = getFlagString(cmd, "chart-type")
+	switch opts.Type {
+	case chartTypeLine, chartTypeStackedBar, chartTypeArea:
+	default:
+		return opts, errors.New("chart-type needs to be line, stacked-bar or area")
+	}
+	opts.Format = getFlagString(cmd, "chart-format")
+	switch opts.Format {
+	case chartFormatPNG, chartFormatSVG, chartFormatHTML:
+	default:
+		return opts, errors.New("chart-format needs to be png, svg or html")
+	}
+	opts.Command = cmd.Name()
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		value := f.Value.String()
+		if redactedParams[f.Name] {
+			value = "redacted"
+		}
+		opts.Params = append(opts.Params, chartParam{Name: f.Name, Value: value})
+	})
+	return opts, nil
+}
+
+// drawSeriesChart renders series to <fileRoot>.png or .svg, with the counts behind it in <fileRoot>-chart.csv,
+// or to a single <fileRoot>.html
+func drawSeriesChart(opts chartOptions, title string, series *seriesSet, fileRoot string) error {
+
+	spec := chartSpec{
+		Title:  title,
+		XLabel: opts.Bucket,
+		YLabel: "count",
+		Type:   opts.Type,
+		Series: series.list(),
+	}
+	buffer := bytes.NewBuffer([]byte{})
+	if opts.Format == chartFormatHTML {
+		var svg bytes.Buffer
+		if err := renderChart(spec, chartFormatSVG, &svg); err != nil {
+			return err
+		}
+		report := chartReport{Title: title, Command: opts.Command, Params: opts.Params, SVG: template.HTML(svg.String()), Rows: series.rows()}
+		if err := chartReportTemplate.Execute(buffer, report); err != nil {
+			return err
+		}
+	} else {
+		if err := writeDataSetToFile(fileRoot+"-chart.csv", series.csv()); err != nil {
+			return err
+		}
+		if err := renderChart(spec, opts.Format, buffer); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(fileRoot + "." + opts.Format)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buffer.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// renderChart draws every series of spec, in order, as a png or svg
+func renderChart(spec chartSpec, format string, w io.Writer) error {
+
+	if len(spec.Series) == 0 {
+		return errors.New("nothing to chart")
+	}
+	starts := spec.Series[0].Starts
+	first := util.Time.ToFloat64(starts[0])
+	last := util.Time.ToFloat64(starts[len(starts)-1])
+	step := util.Time.ToFloat64(nextBucket(starts[len(starts)-1], spec.Series[0].Bucket)) - last
+
+	xrange := &chart.ContinuousRange{Min: first, Max: last}
+	if spec.Type == chartTypeStackedBar || first == last {
+		// leave room for half a bar on either side
+		xrange = &chart.ContinuousRange{Min: first - step/2, Max: last + step/2}
+	}
+
+	var series []chart.Series
+	maxCount := 1.0
+	switch spec.Type {
+	case chartTypeStackedBar:
+		bottoms := make([]float64, len(starts))
+		for _, s := range spec.Series {
+			tops := make([]float64, len(starts))
+			for i := range starts {
+				tops[i] = bottoms[i] + s.Counts[i]
+				if tops[i] > maxCount {
+					maxCount = tops[i]
+				}
+			}
+			series = append(series, stackedBarSeries{Name: s.Name, XValues: starts, Bottoms: bottoms, Tops: tops, BarWidth: step})
+			bottoms = tops
+		}
+	default:
+		for i, s := range spec.Series {
+			style := chart.Style{}
+			if spec.Type == chartTypeArea {
+				color := chart.GetDefaultColor(i)
+				style = chart.Style{Show: true, StrokeColor: color, FillColor: color.WithAlpha(64)}
+			}
+			for _, c := range s.Counts {
+				if c > maxCount {
+					maxCount = c
+				}
+			}
+			series = append(series, chart.TimeSeries{Name: s.Name, Style: style, XValues: s.Starts, YValues: s.Counts})
+		}
+	}
+
+	graph := chart.Chart{
+		Title:      spec.Title,
+		TitleStyle: chart.Style{Show: spec.Title != ""},
+		XAxis: chart.XAxis{
+			Name:           spec.XLabel,
+			NameStyle:      chart.Style{Show: spec.XLabel != ""},
+			Style:          chart.Style{Show: true},
+			Range:          xrange,
+			ValueFormatter: chart.TimeValueFormatterWithFormat("2006-01-02"),
+		},
+		YAxis: chart.YAxis{
+			Name:      spec.YLabel,
+			NameStyle: chart.Style{Show: spec.YLabel != ""},
+			Style:     chart.Style{Show: true},
+			Range:     &chart.ContinuousRange{Min: 0, Max: maxCount},
+		},
+		Background: chart.Style{
+			Padding: chart.Box{
+				Top:  20,
+				Left: 20,
+			},
+		},
+		Series: series,
+	}
+
+	graph.Elements = []chart.Renderable{
+		chart.Legend(&graph),
+	}
+	if format == chartFormatSVG {
+		return graph.Render(chart.SVG, w)
+	}
+	return graph.Render(chart.PNG, w)
+}
+
+type chartReport struct {
+	Title   string
+	Command string
+	Params  []chartParam
+	SVG     template.HTML
+	Rows    [][]string
+}
+
+var chartReportTemplate = template.Must(template.New("chart").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-top: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>command</th><td>{{.Command}}</td></tr>
+{{- range .Params}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+<div>{{.SVG}}</div>
+<table>
+{{- range $i, $row := .Rows}}
+<tr>{{range $row}}{{if eq $i 0}}<th>{{.}}</th>{{else}}<td>{{.}}</td>{{end}}{{end}}</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// stackedBarSeries is one layer of a stacked bar chart: a bar per bucket from Bottoms[i] to Tops[i]
+type stackedBarSeries struct {
+	Name     string
+	XValues  []time.Time
+	Bottoms  []float64
+	Tops     []float64
+	BarWidth float64
+}
+
+func (s stackedBarSeries) GetName() string           { return s.Name }
+func (s stackedBarSeries) GetYAxis() chart.YAxisType { return chart.YAxisPrimary }
+func (s stackedBarSeries) GetStyle() chart.Style     { return chart.Style{} }
+func (s stackedBarSeries) Len() int                  { return len(s.XValues) }
+func (s stackedBarSeries) GetValues(i int) (x, y float64) {
+	return util.Time.ToFloat64(s.XValues[i]), s.Tops[i]
+}
+
+func (s stackedBarSeries) Validate() error {
+	if len(s.XValues) != len(s.Bottoms) || len(s.XValues) != len(s.Tops) {
+		return errors.New("stacked bar series needs a bottom and a top per bucket")
+	}
+	return nil
+}
+
+func (s stackedBarSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, defaults chart.Style) {
+	style := chart.Style{FillColor: defaults.StrokeColor, StrokeColor: defaults.StrokeColor, StrokeWidth: 1}
+	halfWidth := util.Math.MaxInt(1, int(float64(xrange.Translate(xrange.GetMin()+s.BarWidth))*0.4))
+	for i := range s.XValues {
+		if s.Tops[i] == s.Bottoms[i] {
+			continue
+		}
+		x := canvasBox.Left + xrange.Translate(util.Time.ToFloat64(s.XValues[i]))
+		bar := chart.Box{
+			Left:   x - halfWidth,
+			Right:  x + halfWidth,
+			Top:    canvasBox.Bottom - yrange.Translate(s.Tops[i]),
+			Bottom: canvasBox.Bottom - yrange.Translate(s.Bottoms[i]),
+		}
+		chart.Draw.Box(r, bar, style)
+	}
+}
@@ -28,7 +28,6 @@ import (
 	"context"
 	"os"
 
-	"github.com/ctava/github-teamwork/github"
 	"github.com/spf13/cobra"
 )
 
@@ -37,16 +36,12 @@ var discussionCmdName = "teamdiscussion"
 // discussionCmd prints out contributions to dicussions
 var discussionCmd = &cobra.Command{
 	Use:   discussionCmdName,
-	Short: discussionCmdName + " org team user startDay endDay",
-	Long:  discussionCmdName + ` org team user startDay endDay: prints out team discussion comments by date, user. includes reactions (total count, :+1:, :-1:, :laugh:, :confused:, :heart: and :hooray:)`,
+	Short: discussionCmdName + " org team [user|roster] startDay endDay",
+	Long:  discussionCmdName + ` org team [user|roster] startDay endDay: prints out team discussion comments by date, user. includes reactions (total count, :+1:, :-1:, :laugh:, :confused:, :heart: and :hooray:)`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		githubAuthToken := os.Getenv("GITHUB_ACCESS_TOKEN")
-		if githubAuthToken == "" {
-			fmt.Println("warning: will be limited to 60 calls per hour without a token")
-		}
 		ctx := context.Background()
-		fetcher := github.NewFetcher(ctx, githubAuthToken)
+		fetcher := newReportFetcher(ctx, cmd)
 
 		team := getFlagString(cmd, "team")
 		values := strings.Split(team, "/")
@@ -56,7 +51,11 @@ var discussionCmd = &cobra.Command{
 		}
 		org, teamName := values[0], values[1]
 
-		user := getFlagString(cmd, "user")
+		members, label, merr := getMembers(ctx, cmd, fetcher)
+		if merr != nil {
+			fmt.Println("error:", merr)
+			return
+		}
 		start := getFlagString(cmd, "start")
 		end := getFlagString(cmd, "end")
 		startTime, sterr := time.Parse("2006-01-02", start)
@@ -64,37 +63,58 @@ var discussionCmd = &cobra.Command{
 			fmt.Println("an error occurred while parsing the start time. err:", sterr)
 			return
 		}
-		startYear := startTime.Year()
-		startMonth := startTime.Month()
 		endTime, eterr := time.Parse("2006-01-02", end)
 		if eterr != nil {
 			fmt.Println("an error occurred while parsing the end time. err:", eterr)
 			return
 		}
-		endYear := endTime.Year()
-		endMonth := endTime.Month()
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartOpts, oerr := getChartOptions(cmd)
+		if oerr != nil {
+			fmt.Println("error:", oerr)
+			return
+		}
+		series, serr := newSeriesSet(startTime, endTime, chartOpts.Bucket)
+		if serr != nil {
+			fmt.Println("error:", serr)
+			return
+		}
 
 		discussionComments, err := fetcher.FetchTeamDiscussionComments(ctx, org, teamName)
 		if err != nil {
 			fmt.Println("an error occurred while fetching PR Comments err:", err)
 			return
 		}
-		var timeSeriesDataSet []byte
-		fmt.Printf("%s,%s,%s,%s,%s,%s,%s,%s,%s,%s \n", "created_date", "handle", "body", "reaction_total_count", "reaction_plusone", "reaction_minusone", "reaction_laugh", "reaction_confused", "reaction_heart", "reaction_hooray")
+		for _, m := range members {
+			series.get(memberSeriesName(discussionCmdName, members, m))
+		}
+		out := newRecordWriter(os.Stdout, format, []string{"created_date", "created_at", "handle", "body", "reaction_total_count", "reaction_plusone", "reaction_minusone", "reaction_laugh", "reaction_confused", "reaction_heart", "reaction_hooray"})
 		for _, c := range discussionComments {
-			if strings.Compare(user, c.Handle) == 0 {
-				if strings.Compare(c.CreatedAt, start) != -1 {
-					if strings.Compare(c.CreatedAt, end) != 1 {
-						fmt.Printf("%s,%s,%s,%v,%v,%v,%v,%v,%v,%v \n", c.CreatedAt, c.Handle, c.Body, c.ReactionTotalCount, c.ReactionPlusOne, c.ReactionMinusOne, c.ReactionLaugh, c.ReactionConfused, c.ReactionHeart, c.ReactionHooray)
-						timeSeriesDataSet = append(timeSeriesDataSet, c.CreatedAt...)
-						timeSeriesDataSet = append(timeSeriesDataSet, "\n"...)
+			createdAt := zones.day(c.Handle, c.CreatedAt)
+			if containsHandle(members, c.Handle) {
+				if strings.Compare(createdAt, start) != -1 {
+					if strings.Compare(createdAt, end) != 1 {
+						out.write(c, append([]string{createdAt, zones.timestamp(c.Handle, c.CreatedAt), c.Handle, c.Body}, itoas(c.ReactionTotalCount, c.ReactionPlusOne, c.ReactionMinusOne, c.ReactionLaugh, c.ReactionConfused, c.ReactionHeart, c.ReactionHooray)...))
+						series.addDay(memberSeriesName(discussionCmdName, members, c.Handle), createdAt)
 					}
 				}
 			}
 		}
-		fileRoot := start + "-" + user + "-" + discussionCmdName
-		writeDataSetToFile(fileRoot+".csv", timeSeriesDataSet)
-		derr := drawChart(startYear, endYear, startMonth, endMonth, discussionCmdName, fileRoot+".csv", fileRoot+".png")
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+		fileRoot := start + "-" + label + "-" + discussionCmdName
+		derr := drawSeriesChart(chartOpts, discussionCmdName+" "+label+" "+start+".."+end, series, fileRoot)
 		if derr != nil {
 			fmt.Println("an error occurred while drawing the chart. err:", derr)
 			return
@@ -105,11 +125,14 @@ var discussionCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(discussionCmd)
 	discussionCmd.Flags().StringP("team", "T", "", "team to search for discussion threads")
-	discussionCmd.Flags().StringP("user", "U", "", "commenter to search for")
+	discussionCmd.Flags().StringP("user", "U", "", "commenter to search for (default: every member of the team)")
+	addMemberFlags(discussionCmd)
 	discussionCmd.Flags().StringP("start", "S", "", "comment start day")
 	discussionCmd.Flags().StringP("end", "E", "", "comment end day")
+	addChartFlags(discussionCmd)
+	addOutputFlags(discussionCmd)
+	addStoreFlags(discussionCmd)
 	discussionCmd.MarkFlagRequired("team")
-	discussionCmd.MarkFlagRequired("user")
 	discussionCmd.MarkFlagRequired("start")
 	discussionCmd.MarkFlagRequired("end")
 }
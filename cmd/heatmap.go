@@ -0,0 +1,385 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+	chart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
+)
+
+var heatmapCmdName = "heatmap"
+
+// heatmap sources
+const (
+	sourcePRComments     = "prcomments"
+	sourceRepoEvents     = "repoevents"
+	sourceTeamDiscussion = "teamdiscussion"
+)
+
+// heatmapCmd counts activity per weekday and hour, to spot after-hours and weekend work
+var heatmapCmd = &cobra.Command{
+	Use:   heatmapCmdName,
+	Short: heatmapCmdName + " [repo] user|team|roster start_day end_day",
+	Long:  heatmapCmdName + ` [repo] user|team|roster start_day end_day: counts pull request comments, repo events and team discussion comments per weekday and hour of the day, in each member's time zone. prints the 7x24 grid and draws it as a png or svg heatmap`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		ctx := context.Background()
+		fetcher := newReportFetcher(ctx, cmd)
+
+		members, label, merr := getMembers(ctx, cmd, fetcher)
+		if merr != nil {
+			fmt.Println("error:", merr)
+			return
+		}
+		start := getFlagString(cmd, "start")
+		end := getFlagString(cmd, "end")
+		if _, sterr := time.Parse("2006-01-02", start); sterr != nil {
+			fmt.Println("an error occurred while parsing the start time. err:", sterr)
+			return
+		}
+		if _, eterr := time.Parse("2006-01-02", end); eterr != nil {
+			fmt.Println("an error occurred while parsing the end time. err:", eterr)
+			return
+		}
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartFormat := getFlagString(cmd, "chart-format")
+		if chartFormat != chartFormatPNG && chartFormat != chartFormatSVG {
+			fmt.Println("error: chart-format needs to be png or svg")
+			return
+		}
+
+		var grid heatmapGrid
+		count := func(handle string, t time.Time) {
+			day := zones.day(handle, t)
+			if containsHandle(members, handle) && strings.Compare(day, start) != -1 && strings.Compare(day, end) != 1 {
+				grid.add(t.In(zones.zone(handle)))
+			}
+		}
+
+		sources, serr := cmd.Flags().GetStringSlice("sources")
+		checkError(serr)
+		if len(sources) == 0 {
+			if getGitDir(cmd) != "" {
+				sources = append(sources, sourceRepoEvents)
+			} else if len(getRepoFlag(cmd)) > 0 {
+				sources = append(sources, sourcePRComments, sourceRepoEvents)
+			}
+			if getFlagString(cmd, "team") != "" {
+				sources = append(sources, sourceTeamDiscussion)
+			}
+		}
+		if len(sources) == 0 {
+			fmt.Println("error: no --repo or --team to count activity in")
+			return
+		}
+		for _, source := range sources {
+			switch source {
+			case sourcePRComments, sourceRepoEvents:
+				if len(getRepoFlag(cmd)) == 0 {
+					fmt.Println("error: the", source, "source needs a --repo")
+					return
+				}
+			case sourceTeamDiscussion:
+				if getFlagString(cmd, "team") == "" {
+					fmt.Println("error: the", source, "source needs a --team")
+					return
+				}
+			default:
+				fmt.Println("error: sources need to be prcomments, repoevents or teamdiscussion")
+				return
+			}
+		}
+
+		if containsString(sources, sourcePRComments) || containsString(sources, sourceRepoEvents) {
+			repos, rerr := getRepos(ctx, cmd, fetcher)
+			if rerr != nil {
+				fmt.Println("error:", rerr)
+				return
+			}
+			commentsByRepo := make([][]github.PullComment, len(repos))
+			eventsByRepo := make([][]github.RepoEvent, len(repos))
+			ferr := github.ForEach(getFlagInt(cmd, "threads"), len(repos), func(i int) error {
+				var err error
+				if containsString(sources, sourcePRComments) {
+					if commentsByRepo[i], err = fetcher.FetchPullRequestComments(ctx, repos[i]); err != nil {
+						return err
+					}
+				}
+				if containsString(sources, sourceRepoEvents) {
+					eventsByRepo[i], err = fetcher.FetchRepoEvents(ctx, repos[i])
+				}
+				return err
+			})
+			if ferr != nil {
+				fmt.Println("an error occurred while fetching activity. err:", ferr)
+				return
+			}
+			for i := range repos {
+				for _, c := range commentsByRepo[i] {
+					count(c.Handle, c.CreatedAt)
+				}
+				for _, e := range eventsByRepo[i] {
+					count(e.Handle, e.CreatedAt)
+				}
+			}
+		}
+
+		if containsString(sources, sourceTeamDiscussion) {
+			values := strings.Split(getFlagString(cmd, "team"), "/")
+			if len(values) < 2 {
+				fmt.Println("error: team name needs to be owner/teamname")
+				return
+			}
+			discussionComments, err := fetcher.FetchTeamDiscussionComments(ctx, values[0], values[1])
+			if err != nil {
+				fmt.Println("an error occurred while fetching discussion comments. err:", err)
+				return
+			}
+			for _, c := range discussionComments {
+				count(c.Handle, c.CreatedAt)
+			}
+		}
+
+		if getFlagBool(cmd, "terminal") {
+			grid.printTerminal(os.Stdout)
+		} else {
+			out := newRecordWriter(os.Stdout, format, append([]string{"weekday"}, heatmapHours()...))
+			for d, weekday := range heatmapWeekdays {
+				row := []string{weekday}
+				for _, c := range grid[d] {
+					row = append(row, strconv.Itoa(c))
+				}
+				out.write(heatmapRecord{Weekday: weekday, Hours: grid[d][:]}, row)
+			}
+			if err := out.flush(); err != nil {
+				fmt.Println("an error occurred while printing. err:", err)
+				return
+			}
+		}
+
+		buffer := bytes.NewBuffer([]byte{})
+		if err := renderHeatmap(heatmapCmdName+" "+label+" "+start+".."+end, grid, chartFormat, buffer); err != nil {
+			fmt.Println("an error occurred while drawing the heatmap. err:", err)
+			return
+		}
+		fileName := start + "-" + label + "-" + heatmapCmdName + "." + chartFormat
+		if err := writeDataSetToFile(fileName, buffer.Bytes()); err != nil {
+			fmt.Println("an error occurred while writing the heatmap. err:", err)
+			return
+		}
+	},
+}
+
+// heatmapWeekdays the grid's rows, weeks start on monday
+var heatmapWeekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// heatmapGrid counts activity by weekday (monday first) and hour of the day
+type heatmapGrid [7][24]int
+
+type heatmapRecord struct {
+	Weekday string `json:"weekday"`
+	Hours   []int  `json:"hours"`
+}
+
+// add counts t at its weekday and hour, in the time zone of t
+func (g *heatmapGrid) add(t time.Time) {
+	g[(int(t.Weekday())+6)%7][t.Hour()]++
+}
+
+func (g *heatmapGrid) max() int {
+	max := 0
+	for d := range g {
+		for _, c := range g[d] {
+			if c > max {
+				max = c
+			}
+		}
+	}
+	return max
+}
+
+// level shades a count from 0 (none) to 4 (the busiest hour)
+func (g *heatmapGrid) level(count, max int) int {
+	if count == 0 {
+		return 0
+	}
+	level := 1 + 3*count/max
+	if level > 4 {
+		level = 4
+	}
+	return level
+}
+
+// afterHours counts the activity on weekends and on weekdays before 9:00 or from 18:00 on
+func (g *heatmapGrid) afterHours() (weekend, evenings, total int) {
+	for d := range g {
+		for h, c := range g[d] {
+			total += c
+			switch {
+			case d >= 5:
+				weekend += c
+			case h < 9 || h >= 18:
+				evenings += c
+			}
+		}
+	}
+	return weekend, evenings, total
+}
+
+func heatmapHours() []string {
+	hours := make([]string, 24)
+	for h := range hours {
+		hours[h] = fmt.Sprintf("%02d", h)
+	}
+	return hours
+}
+
+var terminalShades = []string{"· ", "░░", "▒▒", "▓▓", "██"}
+
+// printTerminal draws the grid with shade characters, two per hour
+func (g *heatmapGrid) printTerminal(w io.Writer) {
+	fmt.Fprint(w, "    ")
+	for h := 0; h < 24; h += 3 {
+		fmt.Fprintf(w, "%-6s", fmt.Sprintf("%02d", h))
+	}
+	fmt.Fprintln(w)
+	max := g.max()
+	for d, weekday := range heatmapWeekdays {
+		fmt.Fprint(w, weekday+" ")
+		for _, c := range g[d] {
+			fmt.Fprint(w, terminalShades[g.level(c, max)])
+		}
+		fmt.Fprintln(w)
+	}
+	weekend, evenings, total := g.afterHours()
+	fmt.Fprintf(w, "\n%d in total, busiest hour %d. weekends: %d (%s), weekday evenings and nights: %d (%s)\n",
+		total, max, weekend, percentOf(weekend, total), evenings, percentOf(evenings, total))
+}
+
+func percentOf(part, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return strconv.Itoa(100*part/total) + "%"
+}
+
+// heatmapColors from no activity to the busiest hour
+var heatmapColors = []drawing.Color{
+	drawing.ColorFromHex("ebedf0"),
+	drawing.ColorFromHex("c6e48b"),
+	drawing.ColorFromHex("7bc96f"),
+	drawing.ColorFromHex("239a3b"),
+	drawing.ColorFromHex("196127"),
+}
+
+// renderHeatmap draws the grid as a png or svg, a row per weekday and a column per hour. a grid
+// without any activity is drawn too, it is an answer as well
+func renderHeatmap(title string, g heatmapGrid, format string, w io.Writer) error {
+	const (
+		cell   = 28
+		left   = 50
+		top    = 60
+		width  = left + 24*cell + 20
+		height = top + 7*cell + 60
+	)
+	provider := chart.PNG
+	if format == chartFormatSVG {
+		provider = chart.SVG
+	}
+	r, err := provider(width, height)
+	if err != nil {
+		return err
+	}
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return err
+	}
+	text := chart.Style{Font: font, FontSize: 10, FontColor: drawing.ColorBlack}
+	chart.Draw.Box(r, chart.Box{Right: width, Bottom: height}, chart.Style{FillColor: drawing.ColorWhite, StrokeColor: drawing.ColorWhite})
+	chart.Draw.Text(r, title, left, 24, chart.Style{Font: font, FontSize: 13, FontColor: drawing.ColorBlack})
+
+	for h := 0; h < 24; h += 3 {
+		chart.Draw.Text(r, fmt.Sprintf("%02d", h), left+h*cell+cell/4, top-8, text)
+	}
+	max := g.max()
+	for d, weekday := range heatmapWeekdays {
+		y := top + d*cell
+		chart.Draw.Text(r, weekday, 12, y+cell/2+4, text)
+		for h, c := range g[d] {
+			x := left + h*cell
+			box := chart.Box{Left: x + 1, Top: y + 1, Right: x + cell - 1, Bottom: y + cell - 1}
+			color := heatmapColors[g.level(c, max)]
+			chart.Draw.Box(r, box, chart.Style{FillColor: color, StrokeColor: color, StrokeWidth: 1})
+		}
+	}
+
+	weekend, evenings, total := g.afterHours()
+	legendY := top + 7*cell + 30
+	chart.Draw.Text(r, "less", left, legendY, text)
+	for i, color := range heatmapColors {
+		x := left + 30 + i*16
+		chart.Draw.Box(r, chart.Box{Left: x, Top: legendY - 10, Right: x + 12, Bottom: legendY + 2}, chart.Style{FillColor: color, StrokeColor: color, StrokeWidth: 1})
+	}
+	chart.Draw.Text(r, "more", left+30+len(heatmapColors)*16+4, legendY, text)
+	summary := fmt.Sprintf("%d in total, busiest hour %d. weekends %s, weekday evenings and nights %s",
+		total, max, percentOf(weekend, total), percentOf(evenings, total))
+	chart.Draw.Text(r, summary, left+30+len(heatmapColors)*16+50, legendY, text)
+
+	return r.Save(w)
+}
+
+func init() {
+	RootCmd.AddCommand(heatmapCmd)
+	addRepoFlags(heatmapCmd, "repo whose pull request comments and events to count")
+	heatmapCmd.Flags().StringP("user", "U", "", "user to count")
+	heatmapCmd.Flags().StringP("team", "T", "", "org/team whose members, and discussions, to count (instead of --user)")
+	addMemberFlags(heatmapCmd)
+	heatmapCmd.Flags().StringP("start", "S", "", "activity start day")
+	heatmapCmd.Flags().StringP("end", "E", "", "activity end day")
+	heatmapCmd.Flags().StringSlice("sources", nil, "activity to count: prcomments, repoevents and/or teamdiscussion (default: the ones --repo and --team allow)")
+	heatmapCmd.Flags().String("chart-format", chartFormatPNG, "heatmap format: png or svg")
+	heatmapCmd.Flags().Bool("terminal", false, "print the heatmap with shade characters instead of the counts")
+	addOutputFlags(heatmapCmd)
+	addStoreFlags(heatmapCmd)
+	addGitFlags(heatmapCmd)
+	heatmapCmd.MarkFlagRequired("start")
+	heatmapCmd.MarkFlagRequired("end")
+}
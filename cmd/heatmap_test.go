@@ -0,0 +1,68 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeatmapGrid(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	var g heatmapGrid
+	// monday 2018-01-01 02:00 UTC is sunday 21:00 in New York
+	g.add(time.Date(2018, 1, 1, 2, 0, 0, 0, time.UTC).In(newYork))
+	g.add(time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC))
+	g.add(time.Date(2018, 1, 1, 10, 30, 0, 0, time.UTC))
+	g.add(time.Date(2018, 1, 2, 19, 0, 0, 0, time.UTC))
+	if g[6][21] != 1 || g[0][10] != 2 || g[1][19] != 1 {
+		t.Errorf("grid counts sun 21:00 %d, mon 10:00 %d, tue 19:00 %d", g[6][21], g[0][10], g[1][19])
+	}
+	weekend, evenings, total := g.afterHours()
+	if weekend != 1 || evenings != 1 || total != 4 {
+		t.Errorf("afterHours = %d, %d, %d, want 1, 1, 4", weekend, evenings, total)
+	}
+	max := g.max()
+	if max != 2 || g.level(0, max) != 0 || g.level(1, max) != 2 || g.level(2, max) != 4 {
+		t.Errorf("max %d, levels %d %d %d", max, g.level(0, max), g.level(1, max), g.level(2, max))
+	}
+}
+
+func TestRenderEmptyHeatmap(t *testing.T) {
+	var g heatmapGrid
+	var svg bytes.Buffer
+	if err := renderHeatmap("empty", g, chartFormatSVG, &svg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(svg.String(), "<svg") {
+		t.Error("no svg drawn for a grid without activity")
+	}
+	var terminal bytes.Buffer
+	g.printTerminal(&terminal)
+	if !strings.Contains(terminal.String(), "0 in total") {
+		t.Errorf("terminal heatmap %q", terminal.String())
+	}
+}
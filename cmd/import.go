@@ -0,0 +1,225 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/store"
+	"github.com/spf13/cobra"
+)
+
+var importCmdName = "import"
+
+// importCmd groups the commands loading data from other sources into the local store
+var importCmd = &cobra.Command{
+	Use:   importCmdName,
+	Short: importCmdName + " gharchive",
+	Long:  importCmdName + ` gharchive: loads data from other sources than the github API into the local store, where reports read it with --offline`,
+}
+
+// importGHArchiveCmd stores the events of our repos and users found in GH Archive dumps
+var importGHArchiveCmd = &cobra.Command{
+	Use:   "gharchive",
+	Short: "gharchive file.json.gz|dir... repo|user|team|roster",
+	Long:  `gharchive file.json.gz|dir... repo|user|team|roster: reads GH Archive (https://www.gharchive.org) hourly dumps, e.g. 2018-01-01-15.json.gz, or the dumps in a directory, and stores the events of the given repos and users in the local store. repoevents --offline then reports on years of history without any API calls`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		// a dump holds every public repo, only an explicit -R narrows it, never the clone we run in
+		var patterns []string
+		for _, pattern := range getExplicitRepos(cmd) {
+			pattern = repoKey(pattern)
+			if _, err := path.Match(pattern, ""); err != nil {
+				fmt.Println("error: invalid repo pattern", pattern)
+				return
+			}
+			patterns = append(patterns, pattern)
+		}
+		var members []string
+		if getFlagString(cmd, "user") != "" || getFlagString(cmd, "team") != "" || getFlagString(cmd, "roster") != "" {
+			ctx := context.Background()
+			// only --team needs a fetcher, the archive itself is read without the API
+			var fetcher github.Fetcher
+			if getFlagString(cmd, "team") != "" {
+				fetcher = newReportFetcher(ctx, cmd)
+			}
+			var merr error
+			members, _, merr = getMembers(ctx, cmd, fetcher)
+			if merr != nil {
+				fmt.Println("error:", merr)
+				return
+			}
+		}
+		if len(patterns) == 0 && len(members) == 0 {
+			fmt.Println("error: import needs a --repo or a --user, --team or --roster to keep")
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		files, ferr := archiveFiles(args)
+		if ferr != nil {
+			fmt.Println("an error occurred while listing the dumps. err:", ferr)
+			return
+		}
+
+		db, err := store.Open(getFlagString(cmd, "store"))
+		if err != nil {
+			fmt.Println("an error occurred while opening the store. err:", err)
+			return
+		}
+		defer db.Close()
+
+		imported := make(map[string]*importedRepo)
+		for _, file := range files {
+			eventsByRepo := make(map[string][]github.RepoEvent)
+			err := readArchiveFile(file, func(e github.RepoEvent) error {
+				if !keepArchiveEvent(patterns, members, e) {
+					return nil
+				}
+				key := repoKey(e.Repo)
+				eventsByRepo[key] = append(eventsByRepo[key], e)
+				return nil
+			})
+			if err != nil {
+				fmt.Println("an error occurred while reading", file, "err:", err)
+				return
+			}
+			for repo, events := range eventsByRepo {
+				if err := db.SaveRepoEvents(repo, events); err != nil {
+					fmt.Println("an error occurred while storing the events of", repo, "err:", err)
+					return
+				}
+				if imported[repo] == nil {
+					imported[repo] = &importedRepo{Repo: repo}
+				}
+				imported[repo].add(events)
+			}
+		}
+
+		var repos []string
+		for repo := range imported {
+			repos = append(repos, repo)
+		}
+		sort.Strings(repos)
+		out := newRecordWriter(os.Stdout, format, []string{"repo", "events", "first", "last"})
+		for _, repo := range repos {
+			r := imported[repo]
+			out.write(r, []string{r.Repo, strconv.Itoa(r.Events), r.First, r.Last})
+		}
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+	},
+}
+
+// importedRepo counts the events imported for a repo and the days they span
+type importedRepo struct {
+	Repo   string `json:"repo"`
+	Events int    `json:"events"`
+	First  string `json:"first"`
+	Last   string `json:"last"`
+}
+
+func (r *importedRepo) add(events []github.RepoEvent) {
+	for _, e := range events {
+		r.Events++
+		day := e.CreatedAt.UTC().Format("2006-01-02")
+		if r.First == "" || day < r.First {
+			r.First = day
+		}
+		if day > r.Last {
+			r.Last = day
+		}
+	}
+}
+
+// archiveFiles returns the files given and the *.json.gz files in the directories given, sorted by name
+func archiveFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*.json.gz"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func readArchiveFile(file string, fn func(github.RepoEvent) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return github.ReadArchive(f, fn)
+}
+
+// keepArchiveEvent tells whether an archived event is by one of members and in a repo matching one of
+// patterns, an empty list keeps all
+func keepArchiveEvent(patterns []string, members []string, e github.RepoEvent) bool {
+	if len(members) > 0 && !containsHandle(members, e.Handle) {
+		return false
+	}
+	return len(patterns) == 0 || matchRepo(patterns, e.Repo)
+}
+
+// matchRepo tells whether repo (owner/repo) matches one of the lowercase patterns, in any case
+func matchRepo(patterns []string, repo string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, repoKey(repo)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	RootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importGHArchiveCmd)
+	importGHArchiveCmd.Flags().StringSliceP("repo", "R", nil, "repo whose events to import, owner/repo or a url. repeat it, or use globs like org/* (default: all repos)")
+	importGHArchiveCmd.Flags().StringP("user", "U", "", "only events of this user")
+	importGHArchiveCmd.Flags().StringP("team", "T", "", "only events of the members of this org/team")
+	addMemberFlags(importGHArchiveCmd)
+	addOutputFlags(importGHArchiveCmd)
+	addStoreFlags(importGHArchiveCmd)
+}
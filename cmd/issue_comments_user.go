@@ -0,0 +1,139 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+)
+
+var issueCommentsCmdName = "issuecomments"
+
+// issueCommentsCmd prints out conversation comments on pull requests and issues
+var issueCommentsCmd = &cobra.Command{
+	Use:   issueCommentsCmdName,
+	Short: issueCommentsCmdName + " repo user start_day end_day",
+	Long:  issueCommentsCmdName + ` repo user start_day end_day: prints out conversation comments on pull requests and issues by date, user. includes the surface (pullrequest or issue) and reactions (total count, :+1:, :-1:, :laugh:, :confused:, :heart: and :hooray:)`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		ctx := context.Background()
+		fetcher := newFetcher(ctx, cmd)
+
+		repo, rerr := getRepo(cmd)
+		if rerr != nil {
+			fmt.Println("error:", rerr)
+			return
+		}
+		user := getFlagString(cmd, "user")
+		surface := getFlagString(cmd, "surface")
+		if surface != "" && surface != github.SurfacePullRequest && surface != github.SurfaceIssue {
+			fmt.Printf("error: surface needs to be %s or %s\n", github.SurfacePullRequest, github.SurfaceIssue)
+			return
+		}
+
+		start := getFlagString(cmd, "start")
+		end := getFlagString(cmd, "end")
+		startTime, sterr := time.Parse("2006-01-02", start)
+		if sterr != nil {
+			fmt.Println("an error occurred while parsing the start time. err:", sterr)
+			return
+		}
+		endTime, eterr := time.Parse("2006-01-02", end)
+		if eterr != nil {
+			fmt.Println("an error occurred while parsing the end time. err:", eterr)
+			return
+		}
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartOpts, oerr := getChartOptions(cmd)
+		if oerr != nil {
+			fmt.Println("error:", oerr)
+			return
+		}
+		series, serr := newSeriesSet(startTime, endTime, chartOpts.Bucket)
+		if serr != nil {
+			fmt.Println("error:", serr)
+			return
+		}
+		// a user without any matches still gets a zero line
+		series.get(issueCommentsCmdName)
+
+		issueComments, ferr := fetcher.FetchIssueComments(ctx, repo)
+		if ferr != nil {
+			fmt.Println("an error occurred while fetching Issue Comments. err:", ferr)
+			return
+		}
+		out := newRecordWriter(os.Stdout, format, []string{"created_date", "created_at", "handle", "surface", "number", "body", "reaction_total_count", "reaction_plusone", "reaction_minusone", "reaction_laugh", "reaction_confused", "reaction_heart", "reaction_hooray"})
+		for _, c := range issueComments {
+			if surface != "" && surface != c.Surface {
+				continue
+			}
+			createdAt := zones.day(c.Handle, c.CreatedAt)
+			if strings.EqualFold(user, c.Handle) {
+				if strings.Compare(createdAt, start) != -1 {
+					if strings.Compare(createdAt, end) != 1 {
+						out.write(c, append([]string{createdAt, zones.timestamp(c.Handle, c.CreatedAt), c.Handle, c.Surface, strconv.Itoa(c.Number), c.Body}, itoas(c.ReactionTotalCount, c.ReactionPlusOne, c.ReactionMinusOne, c.ReactionLaugh, c.ReactionConfused, c.ReactionHeart, c.ReactionHooray)...))
+						series.addDay(issueCommentsCmdName, createdAt)
+					}
+				}
+			}
+		}
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+		fileRoot := start + "-" + user + "-" + issueCommentsCmdName
+		derr := drawSeriesChart(chartOpts, issueCommentsCmdName+" "+user+" "+start+".."+end, series, fileRoot)
+		if derr != nil {
+			fmt.Println("an error occurred while drawing the chart. err:", derr)
+			return
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(issueCommentsCmd)
+	issueCommentsCmd.Flags().StringP("repo", "R", "", "repo to search for conversation comments, owner/repo or a url (default: the remote of the git clone in the working directory)")
+	issueCommentsCmd.Flags().StringP("user", "U", "", "commenter to search for")
+	issueCommentsCmd.Flags().StringP("start", "S", "", "comment start day")
+	issueCommentsCmd.Flags().StringP("end", "E", "", "comment end day")
+	issueCommentsCmd.Flags().String("surface", "", "only print comments left on a pullrequest or an issue")
+	addChartFlags(issueCommentsCmd)
+	addOutputFlags(issueCommentsCmd)
+	issueCommentsCmd.MarkFlagRequired("user")
+	issueCommentsCmd.MarkFlagRequired("start")
+	issueCommentsCmd.MarkFlagRequired("end")
+}
@@ -0,0 +1,149 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// output formats
+const (
+	formatCSV      = "csv"
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatMarkdown = "markdown"
+	formatTable    = "table"
+)
+
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().String("format", formatCSV, "output format: csv, json, ndjson, markdown or table")
+}
+
+func getOutputFormat(cmd *cobra.Command) (string, error) {
+	format := getFlagString(cmd, "format")
+	switch format {
+	case formatCSV, formatJSON, formatNDJSON, formatMarkdown, formatTable:
+		return format, nil
+	}
+	return "", errors.New("format needs to be csv, json, ndjson, markdown or table")
+}
+
+// recordWriter prints one table of records in an output format. csv, markdown and table print
+// the header and the row of every record, json and ndjson marshal the records themselves so
+// their schema follows the record's struct
+type recordWriter struct {
+	format  string
+	w       io.Writer
+	header  []string
+	rows    [][]string
+	records []interface{}
+	csv     *csv.Writer
+}
+
+func newRecordWriter(w io.Writer, format string, header []string) *recordWriter {
+	rw := &recordWriter{format: format, w: w, header: header}
+	if format == formatCSV {
+		rw.csv = csv.NewWriter(w)
+		rw.csv.Write(header)
+	}
+	return rw
+}
+
+// write prints or collects a record. row holds its values in header order
+func (rw *recordWriter) write(record interface{}, row []string) error {
+	switch rw.format {
+	case formatCSV:
+		return rw.csv.Write(row)
+	case formatNDJSON:
+		return json.NewEncoder(rw.w).Encode(record)
+	case formatJSON:
+		rw.records = append(rw.records, record)
+	default:
+		rw.rows = append(rw.rows, row)
+	}
+	return nil
+}
+
+// flush prints whatever write collected. it needs to be called once every record was written
+func (rw *recordWriter) flush() error {
+	switch rw.format {
+	case formatCSV:
+		rw.csv.Flush()
+		return rw.csv.Error()
+	case formatJSON:
+		records := rw.records
+		if records == nil {
+			records = []interface{}{}
+		}
+		encoder := json.NewEncoder(rw.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case formatMarkdown:
+		fmt.Fprintf(rw.w, "| %s |\n", strings.Join(markdownCells(rw.header), " | "))
+		fmt.Fprintf(rw.w, "|%s\n", strings.Repeat(" --- |", len(rw.header)))
+		for _, row := range rw.rows {
+			fmt.Fprintf(rw.w, "| %s |\n", strings.Join(markdownCells(row), " | "))
+		}
+	case formatTable:
+		tw := tabwriter.NewWriter(rw.w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(tableCells(rw.header), "\t"))
+		for _, row := range rw.rows {
+			fmt.Fprintln(tw, strings.Join(tableCells(row), "\t"))
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+func markdownCells(row []string) []string {
+	r := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	cells := make([]string, len(row))
+	for i, v := range row {
+		cells[i] = r.Replace(v)
+	}
+	return cells
+}
+
+func tableCells(row []string) []string {
+	r := strings.NewReplacer("\r\n", " ", "\n", " ", "\t", " ")
+	cells := make([]string, len(row))
+	for i, v := range row {
+		cells[i] = r.Replace(v)
+	}
+	return cells
+}
+
+func itoas(values ...int) []string {
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = strconv.Itoa(v)
+	}
+	return cells
+}
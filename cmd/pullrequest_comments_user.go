@@ -36,19 +36,23 @@ var pullrequestCommentsCmdName = "prcomments"
 // pullrequestCommentsCmd prints out pull request comments and reactions
 var pullrequestCommentsCmd = &cobra.Command{
 	Use:   pullrequestCommentsCmdName,
-	Short: pullrequestCommentsCmdName + " repo user start_day end_day",
-	Long:  pullrequestCommentsCmdName + ` repo user start_day end_day: prints out pull request comments by date, user. includes reactions (total count, :+1:, :-1:, :laugh:, :confused:, :heart: and :hooray:)`,
+	Short: pullrequestCommentsCmdName + " repo user|team|roster start_day end_day",
+	Long:  pullrequestCommentsCmdName + ` repo user|team|roster start_day end_day: prints out pull request comments by date, user. includes reactions (total count, :+1:, :-1:, :laugh:, :confused:, :heart: and :hooray:)`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		githubAuthToken := os.Getenv("GITHUB_ACCESS_TOKEN")
-		if githubAuthToken == "" {
-			fmt.Println("warning: without a token, you will be limited to 60 calls per hour")
-		}
 		ctx := context.Background()
-		fetcher := github.NewFetcher(ctx, githubAuthToken)
+		fetcher := newReportFetcher(ctx, cmd)
 
-		repo := getFlagString(cmd, "repo")
-		user := getFlagString(cmd, "user")
+		repos, rerr := getRepos(ctx, cmd, fetcher)
+		if rerr != nil {
+			fmt.Println("error:", rerr)
+			return
+		}
+		members, label, merr := getMembers(ctx, cmd, fetcher)
+		if merr != nil {
+			fmt.Println("error:", merr)
+			return
+		}
 
 		start := getFlagString(cmd, "start")
 		end := getFlagString(cmd, "end")
@@ -57,40 +61,69 @@ var pullrequestCommentsCmd = &cobra.Command{
 			fmt.Println("an error occurred while parsing the start time. err:", sterr)
 			return
 		}
-		startYear := startTime.Year()
-		startMonth := startTime.Month()
 		endTime, eterr := time.Parse("2006-01-02", end)
 		if eterr != nil {
 			fmt.Println("an error occurred while parsing the end time. err:", eterr)
 			return
 		}
-		endYear := endTime.Year()
-		endMonth := endTime.Month()
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartOpts, oerr := getChartOptions(cmd)
+		if oerr != nil {
+			fmt.Println("error:", oerr)
+			return
+		}
+		series, serr := newSeriesSet(startTime, endTime, chartOpts.Bucket)
+		if serr != nil {
+			fmt.Println("error:", serr)
+			return
+		}
 
-		var prComments []github.PullComment
-		prComments, ferr := fetcher.FetchPullRequestComments(ctx, repo)
+		commentsByRepo := make([][]github.PullComment, len(repos))
+		ferr := github.ForEach(getFlagInt(cmd, "threads"), len(repos), func(i int) error {
+			var err error
+			commentsByRepo[i], err = fetcher.FetchPullRequestComments(ctx, repos[i])
+			return err
+		})
 		if ferr != nil {
 			fmt.Println("an error occurred while fetching PR Comments. err:", ferr)
 			return
 		}
+		var prComments []github.PullComment
+		for _, comments := range commentsByRepo {
+			prComments = append(prComments, comments...)
+		}
 		var filteredPRComments []github.PullComment
-		var timeSeriesDataSet []byte
-		fmt.Printf("%s,%s,%s,%s,%s,%s,%s,%s,%s,%s \n", "created_date", "handle", "body", "reaction_total_count", "reaction_plusone", "reaction_minusone", "reaction_laugh", "reaction_confused", "reaction_heart", "reaction_hooray")
+		for _, m := range members {
+			series.get(memberSeriesName(pullrequestCommentsCmdName, members, m))
+		}
+		out := newRecordWriter(os.Stdout, format, []string{"created_date", "created_at", "repo", "handle", "body", "reaction_total_count", "reaction_plusone", "reaction_minusone", "reaction_laugh", "reaction_confused", "reaction_heart", "reaction_hooray"})
 		for _, c := range prComments {
-			if strings.Compare(user, c.Handle) == 0 {
-				if strings.Compare(c.CreatedAt, start) != -1 {
-					if strings.Compare(c.CreatedAt, end) != 1 {
-						fmt.Printf("%s,%s,%s,%v,%v,%v,%v,%v,%v,%v \n", c.CreatedAt, c.Handle, c.Body, c.ReactionTotalCount, c.ReactionPlusOne, c.ReactionMinusOne, c.ReactionLaugh, c.ReactionConfused, c.ReactionHeart, c.ReactionHooray)
+			createdAt := zones.day(c.Handle, c.CreatedAt)
+			if containsHandle(members, c.Handle) {
+				if strings.Compare(createdAt, start) != -1 {
+					if strings.Compare(createdAt, end) != 1 {
+						out.write(c, append([]string{createdAt, zones.timestamp(c.Handle, c.CreatedAt), c.Repo, c.Handle, c.Body}, itoas(c.ReactionTotalCount, c.ReactionPlusOne, c.ReactionMinusOne, c.ReactionLaugh, c.ReactionConfused, c.ReactionHeart, c.ReactionHooray)...))
 						filteredPRComments = append(filteredPRComments, c)
-						timeSeriesDataSet = append(timeSeriesDataSet, c.CreatedAt...)
-						timeSeriesDataSet = append(timeSeriesDataSet, "\n"...)
+						series.addDay(memberSeriesName(pullrequestCommentsCmdName, members, c.Handle), createdAt)
 					}
 				}
 			}
 		}
-		fileRoot := start + "-" + user + "-" + pullrequestCommentsCmdName
-		writeDataSetToFile(fileRoot+".csv", timeSeriesDataSet)
-		derr := drawChart(startYear, endYear, startMonth, endMonth, pullrequestCommentsCmdName, fileRoot+".csv", fileRoot+".png")
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+		fileRoot := start + "-" + label + "-" + pullrequestCommentsCmdName
+		derr := drawSeriesChart(chartOpts, pullrequestCommentsCmdName+" "+label+" "+start+".."+end, series, fileRoot)
 		if derr != nil {
 			fmt.Println("an error occurred while drawing the chart. err:", derr)
 			return
@@ -100,12 +133,15 @@ var pullrequestCommentsCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(pullrequestCommentsCmd)
-	pullrequestCommentsCmd.Flags().StringP("repo", "R", "", "repo to search for pull request comments")
+	addRepoFlags(pullrequestCommentsCmd, "repo to search for pull request comments")
 	pullrequestCommentsCmd.Flags().StringP("user", "U", "", "pull request commenter to search for")
+	pullrequestCommentsCmd.Flags().StringP("team", "T", "", "org/team whose members to search for (instead of --user)")
+	addMemberFlags(pullrequestCommentsCmd)
 	pullrequestCommentsCmd.Flags().StringP("start", "S", "", "pull request comment start day")
 	pullrequestCommentsCmd.Flags().StringP("end", "E", "", "pull request comment end day")
-	pullrequestCommentsCmd.MarkFlagRequired("repo")
-	pullrequestCommentsCmd.MarkFlagRequired("user")
+	addChartFlags(pullrequestCommentsCmd)
+	addOutputFlags(pullrequestCommentsCmd)
+	addStoreFlags(pullrequestCommentsCmd)
 	pullrequestCommentsCmd.MarkFlagRequired("start")
 	pullrequestCommentsCmd.MarkFlagRequired("end")
 }
@@ -0,0 +1,130 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var pullrequestReviewsCmdName = "prreviews"
+
+// pullrequestReviewsCmd prints out pull request reviews and their states
+var pullrequestReviewsCmd = &cobra.Command{
+	Use:   pullrequestReviewsCmdName,
+	Short: pullrequestReviewsCmdName + " repo user start_day end_day",
+	Long:  pullrequestReviewsCmdName + ` repo user start_day end_day: prints out pull request reviews by date, user. includes the pull request number and review state (APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED)`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		ctx := context.Background()
+		fetcher := newFetcher(ctx, cmd)
+
+		repo, rerr := getRepo(cmd)
+		if rerr != nil {
+			fmt.Println("error:", rerr)
+			return
+		}
+		user := getFlagString(cmd, "user")
+
+		start := getFlagString(cmd, "start")
+		end := getFlagString(cmd, "end")
+		startTime, sterr := time.Parse("2006-01-02", start)
+		if sterr != nil {
+			fmt.Println("an error occurred while parsing the start time. err:", sterr)
+			return
+		}
+		endTime, eterr := time.Parse("2006-01-02", end)
+		if eterr != nil {
+			fmt.Println("an error occurred while parsing the end time. err:", eterr)
+			return
+		}
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartOpts, oerr := getChartOptions(cmd)
+		if oerr != nil {
+			fmt.Println("error:", oerr)
+			return
+		}
+		series, serr := newSeriesSet(startTime, endTime, chartOpts.Bucket)
+		if serr != nil {
+			fmt.Println("error:", serr)
+			return
+		}
+		// a user without any matches still gets a zero line
+		series.get(pullrequestReviewsCmdName)
+
+		// days are counted in reviewer time zones, so pull requests updated the day before start are fetched too
+		prReviews, ferr := fetcher.FetchPullRequestReviewsSince(ctx, repo, startTime.AddDate(0, 0, -1))
+		if ferr != nil {
+			fmt.Println("an error occurred while fetching PR Reviews. err:", ferr)
+			return
+		}
+		out := newRecordWriter(os.Stdout, format, []string{"submitted_date", "submitted_at", "handle", "pull_number", "state"})
+		for _, r := range prReviews {
+			submittedAt := zones.day(r.Handle, r.SubmittedAt)
+			if strings.EqualFold(user, r.Handle) {
+				if strings.Compare(submittedAt, start) != -1 {
+					if strings.Compare(submittedAt, end) != 1 {
+						out.write(r, []string{submittedAt, zones.timestamp(r.Handle, r.SubmittedAt), r.Handle, strconv.Itoa(r.PullNumber), r.State})
+						series.addDay(pullrequestReviewsCmdName, submittedAt)
+					}
+				}
+			}
+		}
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+		fileRoot := start + "-" + user + "-" + pullrequestReviewsCmdName
+		derr := drawSeriesChart(chartOpts, pullrequestReviewsCmdName+" "+user+" "+start+".."+end, series, fileRoot)
+		if derr != nil {
+			fmt.Println("an error occurred while drawing the chart. err:", derr)
+			return
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(pullrequestReviewsCmd)
+	pullrequestReviewsCmd.Flags().StringP("repo", "R", "", "repo to search for pull request reviews, owner/repo or a url (default: the remote of the git clone in the working directory)")
+	pullrequestReviewsCmd.Flags().StringP("user", "U", "", "pull request reviewer to search for")
+	pullrequestReviewsCmd.Flags().StringP("start", "S", "", "pull request review start day")
+	pullrequestReviewsCmd.Flags().StringP("end", "E", "", "pull request review end day")
+	addChartFlags(pullrequestReviewsCmd)
+	addOutputFlags(pullrequestReviewsCmd)
+	pullrequestReviewsCmd.MarkFlagRequired("user")
+	pullrequestReviewsCmd.MarkFlagRequired("start")
+	pullrequestReviewsCmd.MarkFlagRequired("end")
+}
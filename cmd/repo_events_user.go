@@ -22,13 +22,16 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"context"
 	"os"
+	"sort"
 
 	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/gitlog"
 	"github.com/spf13/cobra"
 )
 
@@ -37,19 +40,23 @@ var repoEventsCmdName = "repoevents"
 // repoEventsCmd prints out events in a repository associated with a user
 var repoEventsCmd = &cobra.Command{
 	Use:   repoEventsCmdName,
-	Short: repoEventsCmdName + " repo user start_day end_day",
-	Long:  repoEventsCmdName + ` repo user start_day end_day: prints out events by date, user)`,
+	Short: repoEventsCmdName + " repo user|team|roster start_day end_day",
+	Long:  repoEventsCmdName + ` repo user|team|roster start_day end_day: prints out events by date, user)`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		githubAuthToken := os.Getenv("GITHUB_ACCESS_TOKEN")
-		if githubAuthToken == "" {
-			fmt.Println("warning: will be limited to 60 calls per hour without a token")
-		}
 		ctx := context.Background()
-		fetcher := github.NewFetcher(ctx, githubAuthToken)
+		fetcher := newReportFetcher(ctx, cmd)
 
-		repo := getFlagString(cmd, "repo")
-		user := getFlagString(cmd, "user")
+		repos, rerr := getRepos(ctx, cmd, fetcher)
+		if rerr != nil {
+			fmt.Println("error:", rerr)
+			return
+		}
+		members, label, merr := getMembers(ctx, cmd, fetcher)
+		if merr != nil {
+			fmt.Println("error:", merr)
+			return
+		}
 		start := getFlagString(cmd, "start")
 		end := getFlagString(cmd, "end")
 		startTime, sterr := time.Parse("2006-01-02", start)
@@ -57,66 +64,151 @@ var repoEventsCmd = &cobra.Command{
 			fmt.Println("an error occurred while parsing the start time. err:", sterr)
 			return
 		}
-		startYear := startTime.Year()
-		startMonth := startTime.Month()
 		endTime, eterr := time.Parse("2006-01-02", end)
 		if eterr != nil {
 			fmt.Println("an error occurred while parsing the end time. err:", eterr)
 			return
 		}
-		endYear := endTime.Year()
-		endMonth := endTime.Month()
+		zones, zerr := getTimeZones(cmd)
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+		chartOpts, oerr := getChartOptions(cmd)
+		if oerr != nil {
+			fmt.Println("error:", oerr)
+			return
+		}
+		series, serr := newSeriesSet(startTime, endTime, chartOpts.Bucket)
+		if serr != nil {
+			fmt.Println("error:", serr)
+			return
+		}
+		eventFilters, everr := cmd.Flags().GetStringSlice("event")
+		checkError(everr)
+		registry, defaultTypes := getEventRegistry(cmd)
+		types, terr := getEventTypes(cmd, registry)
+		if terr != nil {
+			fmt.Println("error:", terr)
+			return
+		}
 
-		var createBranchTimeSeriesDataSet []byte
-		var pushesTimeSeriesDataSet []byte
-		var pullrequestsTimeSeriesDataSet []byte
-		//var issueCreateTimeSeriesDataSet []byte
-		//var pullrequestreviewsTimeSeriesDataSet []byte
-		//var pullrequestreviewcommentsTimeSeriesDataSet []byte
-		var deleteBranchTimeSeriesDataSet []byte
-		var events []github.RepoEvent
-		var err error
-		events, err = fetcher.FetchRepoEvents(ctx, repo)
+		backfill := getFlagBool(cmd, "backfill")
+		local := isLocal(cmd)
+		if !backfill && !local && time.Since(startTime) > github.EventsAPIWindow {
+			fmt.Fprintln(os.Stderr, "warning: the events api only serves the last 90 days, events before",
+				time.Now().Add(-github.EventsAPIWindow).Format("2006-01-02"), "are missing. use --backfill to rebuild them")
+		}
+		// days are counted in member time zones, so the window is a day wider on both sides
+		since := startTime.AddDate(0, 0, -1)
+		until := endTime.AddDate(0, 0, 2)
+
+		eventsByRepo := make([][]github.RepoEvent, len(repos))
+		err := github.ForEach(getFlagInt(cmd, "threads"), len(repos), func(i int) error {
+			var err error
+			eventsByRepo[i], err = fetcher.FetchRepoEvents(ctx, repos[i])
+			if err != nil {
+				return err
+			}
+			// events are newest first. the part of the window before the oldest one is rebuilt
+			cutoff := until
+			if n := len(eventsByRepo[i]); n > 0 && eventsByRepo[i][n-1].CreatedAt.Before(cutoff) {
+				cutoff = eventsByRepo[i][n-1].CreatedAt
+			}
+			if !since.Before(cutoff) {
+				return nil
+			}
+			if !backfill {
+				if !local && len(eventsByRepo[i]) >= github.EventsAPILimit {
+					fmt.Fprintln(os.Stderr, "warning:", repos[i], "has more events than the", github.EventsAPILimit,
+						"the events api serves, events before", cutoff.Format(time.RFC3339), "are missing. use --backfill to rebuild them")
+				}
+				return nil
+			}
+			activity, err := fetcher.FetchRepoActivity(ctx, repos[i], since, cutoff)
+			eventsByRepo[i] = append(eventsByRepo[i], activity...)
+			return err
+		})
 		if err != nil {
 			fmt.Println("an error occurred while fetching events. err:", err)
 			return
 		}
-		fmt.Printf("%s,%s,%s \n", "created_date", "handle", "type")
+		var events []github.RepoEvent
+		for _, repoEvents := range eventsByRepo {
+			for _, e := range repoEvents {
+				createdAt := zones.day(e.Handle, e.CreatedAt)
+				if containsHandle(members, e.Handle) && matchesEvents(e, eventFilters) &&
+					(len(types) == 0 || containsEventType(types, e.Type)) &&
+					strings.Compare(createdAt, start) != -1 && strings.Compare(createdAt, end) != 1 {
+					events = append(events, e)
+				}
+			}
+		}
+		// without --types the chart has a line for every type that occurs
+		if len(types) == 0 {
+			types = occurringEventTypes(registry, defaultTypes, events)
+		}
+
+		var chartedEvents []repoEventLine
+		if len(eventFilters) > 0 {
+			for _, event := range eventFilters {
+				chartedEvents = append(chartedEvents, repoEventLine{Event: event, Name: event})
+			}
+		} else {
+			for _, t := range types {
+				chartedEvents = append(chartedEvents, repoEventLine{Event: t.Name, Name: t.Short})
+			}
+		}
+		// a single user gets a line per event type, a team a line per member
+		if len(members) == 1 {
+			for _, es := range chartedEvents {
+				series.get(es.Name)
+			}
+		} else {
+			for _, m := range members {
+				series.get(m)
+			}
+		}
+
+		var out *recordWriter
+		if getFlagBool(cmd, "summary") {
+			out = newRecordWriter(os.Stdout, format, []string{"type", "description", "events", "handles", "commits"})
+			for _, summary := range summarizeEventTypes(types, events) {
+				out.write(summary, []string{summary.Type, summary.Description, strconv.Itoa(summary.Events), strconv.Itoa(summary.Handles), strconv.Itoa(summary.Commits)})
+			}
+		} else {
+			out = newRecordWriter(os.Stdout, format, []string{"created_date", "created_at", "repo", "handle", "type", "action", "ref", "commits", "source"})
+		}
 		for _, e := range events {
-			if strings.Compare(user, e.Handle) == 0 {
-				if strings.Compare(e.CreatedAt, start) != -1 {
-					if strings.Compare(e.CreatedAt, end) != 1 {
-						fmt.Printf("%s,%s,%s \n", e.CreatedAt, e.Handle, e.Type)
-						if e.Type == "CreateEvent" {
-							createBranchTimeSeriesDataSet = append(createBranchTimeSeriesDataSet, e.CreatedAt...)
-							createBranchTimeSeriesDataSet = append(createBranchTimeSeriesDataSet, "\n"...)
-						}
-						if e.Type == "PushEvent" {
-							pushesTimeSeriesDataSet = append(pushesTimeSeriesDataSet, e.CreatedAt...)
-							pushesTimeSeriesDataSet = append(pushesTimeSeriesDataSet, "\n"...)
-						}
-						if e.Type == "PullRequestEvent" {
-							pullrequestsTimeSeriesDataSet = append(pullrequestsTimeSeriesDataSet, e.CreatedAt...)
-							pullrequestsTimeSeriesDataSet = append(pullrequestsTimeSeriesDataSet, "\n"...)
-						}
-						if e.Type == "DeleteEvent" {
-							deleteBranchTimeSeriesDataSet = append(deleteBranchTimeSeriesDataSet, e.CreatedAt...)
-							deleteBranchTimeSeriesDataSet = append(deleteBranchTimeSeriesDataSet, "\n"...)
-						}
-					}
+			createdAt := zones.day(e.Handle, e.CreatedAt)
+			if !getFlagBool(cmd, "summary") {
+				// events stored before rows were tagged with their source came from the events api
+				if e.Source == "" {
+					e.Source = github.SourceEvents
 				}
+				out.write(e, []string{createdAt, zones.timestamp(e.Handle, e.CreatedAt), e.Repo, e.Handle, e.Type, e.SubAction(), e.Ref, strconv.Itoa(e.Commits), e.Source})
 			}
+			if len(members) > 1 {
+				series.addDay(memberHandle(members, e.Handle), createdAt)
+				continue
+			}
+			for _, es := range chartedEvents {
+				if e.MatchesEvent(es.Event) {
+					series.addDay(es.Name, createdAt)
+				}
+			}
+		}
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
 		}
-		fileRoot := start + "-" + user + "-" + repoEventsCmdName
-		fileRoot1 := start + "-" + user + "-" + "createbranch"
-		writeDataSetToFile(fileRoot1+".csv", createBranchTimeSeriesDataSet)
-		fileRoot2 := start + "-" + user + "-" + "pushes"
-		writeDataSetToFile(fileRoot2+".csv", pushesTimeSeriesDataSet)
-		fileRoot3 := start + "-" + user + "-" + "pullrequests"
-		writeDataSetToFile(fileRoot3+".csv", pullrequestsTimeSeriesDataSet)
-		fileRoot4 := start + "-" + user + "-" + "deletebranch"
-		writeDataSetToFile(fileRoot4+".csv", deleteBranchTimeSeriesDataSet)
-		derr := drawChartWithFourLines(startYear, endYear, startMonth, endMonth, "createbranch", "pushes", "pullrequests", "deletebranch", fileRoot1+".csv", fileRoot2+".csv", fileRoot3+".csv", fileRoot4+".csv", fileRoot+".png")
+		fileRoot := start + "-" + label + "-" + repoEventsCmdName
+		derr := drawSeriesChart(chartOpts, repoEventsCmdName+" "+label+" "+start+".."+end, series, fileRoot)
 		if derr != nil {
 			fmt.Println("an error occurred while drawing the chart. err:", derr)
 			return
@@ -125,14 +217,142 @@ var repoEventsCmd = &cobra.Command{
 	},
 }
 
+// repoEventLine names the chart line the events matching Event, a type or type:subaction, are counted in
+type repoEventLine struct {
+	Event string
+	Name  string
+}
+
+// matchesEvents tells whether e matches one of the --event values, which all events do without any
+func matchesEvents(e github.RepoEvent, events []string) bool {
+	if len(events) == 0 {
+		return true
+	}
+	for _, event := range events {
+		if e.MatchesEvent(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// getEventRegistry returns the event types cmd reports on and the names of the ones charted when no event
+// occurs: the github types, or with --git the types of a local clone as well
+func getEventRegistry(cmd *cobra.Command) ([]github.EventType, []string) {
+	if getGitDir(cmd) == "" {
+		return github.EventTypes, github.DefaultEventTypes
+	}
+	registry := append(append([]github.EventType{}, gitlog.EventTypes...), github.EventTypes...)
+	return registry, []string{gitlog.CommitEvent, gitlog.CoAuthorEvent}
+}
+
+// getEventTypes returns the types of registry --types selects, none without --types
+func getEventTypes(cmd *cobra.Command, registry []github.EventType) ([]github.EventType, error) {
+	names, err := cmd.Flags().GetStringSlice("types")
+	checkError(err)
+	var types []github.EventType
+	for _, name := range names {
+		if name == "all" {
+			return registry, nil
+		}
+		t, err := github.LookupEventType(registry, name)
+		if err != nil {
+			return nil, err
+		}
+		if !containsEventType(types, t.Name) {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// occurringEventTypes returns the types of events in registry order, or the default types when there are no events
+func occurringEventTypes(registry []github.EventType, defaultTypes []string, events []github.RepoEvent) []github.EventType {
+	var types []github.EventType
+	for _, e := range events {
+		if !containsEventType(types, e.Type) {
+			types = append(types, github.EventTypeOf(registry, e.Type))
+		}
+	}
+	if len(types) == 0 {
+		for _, name := range defaultTypes {
+			types = append(types, github.EventTypeOf(registry, name))
+		}
+	}
+	sortEventTypes(registry, types)
+	return types
+}
+
+func containsEventType(types []github.EventType, name string) bool {
+	for _, t := range types {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEventTypes puts types in registry order, followed by the unregistered ones by name
+func sortEventTypes(registry []github.EventType, types []github.EventType) {
+	order := func(t github.EventType) int {
+		for i, r := range registry {
+			if r.Name == t.Name {
+				return i
+			}
+		}
+		return len(registry)
+	}
+	sort.SliceStable(types, func(i, j int) bool {
+		if order(types[i]) != order(types[j]) {
+			return order(types[i]) < order(types[j])
+		}
+		return types[i].Name < types[j].Name
+	})
+}
+
+type eventTypeSummary struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Events      int    `json:"events"`
+	Handles     int    `json:"handles"`
+	Commits     int    `json:"commits"`
+}
+
+// summarizeEventTypes counts the events, distinct handles and pushed commits of each type
+func summarizeEventTypes(types []github.EventType, events []github.RepoEvent) []eventTypeSummary {
+	var summaries []eventTypeSummary
+	for _, t := range types {
+		summary := eventTypeSummary{Type: t.Name, Description: t.Description}
+		handles := make(map[string]bool)
+		for _, e := range events {
+			if e.Type == t.Name {
+				summary.Events++
+				summary.Commits += e.Commits
+				handles[e.Handle] = true
+			}
+		}
+		summary.Handles = len(handles)
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
 func init() {
 	RootCmd.AddCommand(repoEventsCmd)
-	repoEventsCmd.Flags().StringP("repo", "R", "", "repo to search")
+	addRepoFlags(repoEventsCmd, "repo to search")
 	repoEventsCmd.Flags().StringP("user", "U", "", "user to search")
+	repoEventsCmd.Flags().StringP("team", "T", "", "org/team whose members to search (instead of --user)")
+	addMemberFlags(repoEventsCmd)
 	repoEventsCmd.Flags().StringP("start", "S", "", "user events start day")
 	repoEventsCmd.Flags().StringP("end", "E", "", "user events end day")
-	repoEventsCmd.MarkFlagRequired("repo")
-	repoEventsCmd.MarkFlagRequired("user")
+	repoEventsCmd.Flags().StringSlice("event", nil, "only events of this type, or type and sub action like PullRequestEvent:merged, CreateEvent:tag or PullRequestReviewEvent:approved. repeat it to chart a line per event")
+	repoEventsCmd.Flags().StringSlice("types", nil, "only these event types, by name or short name like push, pullrequest, issues or release, commit or coauthor with --git, or all (default: every type that occurs)")
+	repoEventsCmd.Flags().Bool("backfill", false, "rebuild the events the events api no longer serves (older than 90 days or past its 300 events) from commits, pull requests, issues and reviews")
+	repoEventsCmd.Flags().Bool("summary", false, "print a table with the events, handles and pushed commits per type instead of the events")
+	addChartFlags(repoEventsCmd)
+	addOutputFlags(repoEventsCmd)
+	addStoreFlags(repoEventsCmd)
+	addGitFlags(repoEventsCmd)
 	repoEventsCmd.MarkFlagRequired("start")
 	repoEventsCmd.MarkFlagRequired("end")
 }
@@ -0,0 +1,85 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/gitlog"
+)
+
+func TestOccurringEventTypes(t *testing.T) {
+	gitRegistry := append(append([]github.EventType{}, gitlog.EventTypes...), github.EventTypes...)
+	tests := []struct {
+		registry     []github.EventType
+		defaultTypes []string
+		events       []github.RepoEvent
+		want         []string
+	}{
+		{github.EventTypes, github.DefaultEventTypes, nil, []string{"push", "create", "pullrequest", "issues"}},
+		{github.EventTypes, github.DefaultEventTypes,
+			[]github.RepoEvent{{Type: "IssuesEvent"}, {Type: "SponsorshipEvent"}, {Type: "PushEvent"}, {Type: "IssuesEvent"}},
+			[]string{"push", "issues", "sponsorship"}},
+		{gitRegistry, []string{gitlog.CommitEvent, gitlog.CoAuthorEvent}, nil, []string{"commit", "coauthor"}},
+		{gitRegistry, []string{gitlog.CommitEvent, gitlog.CoAuthorEvent},
+			[]github.RepoEvent{{Type: gitlog.CoAuthorEvent}, {Type: "PushEvent"}, {Type: gitlog.CommitEvent}},
+			[]string{"commit", "coauthor", "push"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, t := range occurringEventTypes(test.registry, test.defaultTypes, test.events) {
+			got = append(got, t.Short)
+		}
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("occurringEventTypes(%v) = %v, want %v", test.events, got, test.want)
+		}
+	}
+}
+
+func TestGithubRegistryHasNoCloneTypes(t *testing.T) {
+	for _, name := range []string{gitlog.CommitEvent, gitlog.CoAuthorEvent, "commit", "coauthor"} {
+		if _, err := github.LookupEventType(github.EventTypes, name); err == nil {
+			t.Errorf("%s is a github event type", name)
+		}
+	}
+}
+
+func TestSummarizeEventTypes(t *testing.T) {
+	types := []github.EventType{github.EventTypeOf(github.EventTypes, "PushEvent"), github.EventTypeOf(github.EventTypes, "ReleaseEvent")}
+	events := []github.RepoEvent{
+		{Handle: "a", Type: "PushEvent", Commits: 2},
+		{Handle: "b", Type: "PushEvent", Commits: 1},
+		{Handle: "a", Type: "PushEvent", Commits: 4},
+		{Handle: "a", Type: "IssuesEvent"},
+	}
+	summaries := summarizeEventTypes(types, events)
+	if len(summaries) != 2 {
+		t.Fatalf("%d summaries, want 2", len(summaries))
+	}
+	if s := summaries[0]; s.Events != 3 || s.Handles != 2 || s.Commits != 7 {
+		t.Errorf("push summary %+v", s)
+	}
+	if s := summaries[1]; s.Events != 0 || s.Handles != 0 {
+		t.Errorf("release summary %+v", s)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+)
+
+// addRepoFlags adds a -R that takes several repos and globs, and --topic
+func addRepoFlags(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringSliceP("repo", "R", nil, usage+", owner/repo or a url (default: the remote of the git clone in the working directory). repeat it, or use org/* and globs like org/service-* for several repos")
+	cmd.Flags().String("topic", "", "only repos tagged with this topic")
+}
+
+// getRepoFlag returns the -R values of cmd, which takes one repo or several. without -R it is the
+// repo the git clone in the working directory, or in --git, was cloned from, if there is one
+func getRepoFlag(cmd *cobra.Command) []string {
+	if cmd.Flags().Lookup("repo") == nil {
+		return nil
+	}
+	repos := getExplicitRepos(cmd)
+	if len(repos) == 0 {
+		dir := "."
+		if gitDir := getGitDir(cmd); gitDir != "" {
+			// a clone without a github remote is reported on as local:<name of its directory>
+			dir, repos = gitDir, []string{localRepo(gitDir)}
+		}
+		if ref, err := github.RepoRefFromGitRemote(dir); err == nil {
+			repos = []string{ref.URL()}
+		}
+	}
+	return repos
+}
+
+// getExplicitRepos returns the -R values of cmd as given, without falling back to a git remote
+func getExplicitRepos(cmd *cobra.Command) []string {
+	flag := cmd.Flags().Lookup("repo")
+	if flag == nil {
+		return nil
+	}
+	if flag.Value.Type() == "stringSlice" {
+		repos, err := cmd.Flags().GetStringSlice("repo")
+		checkError(err)
+		return repos
+	}
+	if repo := getFlagString(cmd, "repo"); repo != "" {
+		return []string{repo}
+	}
+	return nil
+}
+
+// localRepo names the clone in dir after its directory
+func localRepo(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return "local:" + filepath.Base(dir)
+}
+
+// getRepo returns the one repo a command reports on
+func getRepo(cmd *cobra.Command) (string, error) {
+	repos := getRepoFlag(cmd)
+	if len(repos) == 0 {
+		return "", errors.New("no --repo given and the working directory is not a clone of a github repo")
+	}
+	return repos[0], nil
+}
+
+// getRepos expands the -R values and --topic into the repos a report covers
+func getRepos(ctx context.Context, cmd *cobra.Command, fetcher github.Fetcher) ([]string, error) {
+	patterns := getRepoFlag(cmd)
+	if len(patterns) == 0 {
+		return nil, errors.New("no --repo given and the working directory is not a clone of a github repo")
+	}
+	return fetcher.ExpandRepos(ctx, patterns, getFlagString(cmd, "topic"))
+}
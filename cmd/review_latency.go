@@ -0,0 +1,338 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+)
+
+var reviewLatencyCmdName = "reviewlatency"
+
+// reviewLatencyCmd prints out review turnaround per pull request, reviewer and team member
+var reviewLatencyCmd = &cobra.Command{
+	Use:   reviewLatencyCmdName,
+	Short: reviewLatencyCmdName + " repo start_day end_day",
+	Long:  reviewLatencyCmdName + ` repo start_day end_day: prints out, per pull request and reviewer, the hours from the pull request being opened (or the review being requested) to the first review, to approval and to merge. followed by the p50/p90 per reviewer for pull requests opened in the date range`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		ctx := context.Background()
+		fetcher := newFetcher(ctx, cmd)
+
+		repo, rerr := getRepo(cmd)
+		if rerr != nil {
+			fmt.Println("error:", rerr)
+			return
+		}
+		user := getFlagString(cmd, "user")
+
+		start := getFlagString(cmd, "start")
+		end := getFlagString(cmd, "end")
+		zone, zerr := time.LoadLocation(getFlagString(cmd, "tz"))
+		if zerr != nil {
+			fmt.Println("error:", zerr)
+			return
+		}
+		startTime, sterr := time.ParseInLocation("2006-01-02", start, zone)
+		if sterr != nil {
+			fmt.Println("an error occurred while parsing the start time. err:", sterr)
+			return
+		}
+		endTime, eterr := time.ParseInLocation("2006-01-02", end, zone)
+		if eterr != nil {
+			fmt.Println("an error occurred while parsing the end time. err:", eterr)
+			return
+		}
+		endTime = endTime.AddDate(0, 0, 1)
+		format, fmterr := getOutputFormat(cmd)
+		if fmterr != nil {
+			fmt.Println("error:", fmterr)
+			return
+		}
+
+		pullRequests, ferr := fetcher.FetchPullRequestsSince(ctx, repo, startTime)
+		if ferr != nil {
+			fmt.Println("an error occurred while fetching PRs. err:", ferr)
+			return
+		}
+		// only the pull requests opened in the date range are reported on, so only their reviews and review
+		// requests are fetched
+		var pullNumbers []int
+		for _, pr := range pullRequests {
+			if !pr.CreatedAt.Before(startTime) && pr.CreatedAt.Before(endTime) {
+				pullNumbers = append(pullNumbers, pr.Number)
+			}
+		}
+		prReviews, ferr := fetcher.FetchPullRequestReviewsOf(ctx, repo, pullNumbers)
+		if ferr != nil {
+			fmt.Println("an error occurred while fetching PR Reviews. err:", ferr)
+			return
+		}
+		reviewRequests, ferr := fetcher.FetchReviewRequestsOf(ctx, repo, pullNumbers)
+		if ferr != nil {
+			fmt.Println("an error occurred while fetching PR Review Requests. err:", ferr)
+			return
+		}
+
+		latencies := getReviewLatencies(pullRequests, prReviews, reviewRequests, startTime, endTime)
+		out := newRecordWriter(os.Stdout, format, []string{"pull_number", "author", "reviewer", "waiting_since", "hours_to_first_review", "hours_to_approval", "hours_to_merge"})
+		for _, l := range latencies {
+			if user != "" && !strings.EqualFold(user, l.Reviewer) {
+				continue
+			}
+			record := reviewLatencyRecord{PullNumber: l.PullNumber, Author: l.Author, Reviewer: l.Reviewer, WaitingSince: l.WaitingSince.In(zone),
+				HoursToFirstReview: hours(l.ToFirstReview), HoursToApproval: hours(l.ToApproval), HoursToMerge: hours(l.ToMerge)}
+			out.write(record, []string{strconv.Itoa(l.PullNumber), l.Author, l.Reviewer, l.WaitingSince.In(zone).Format(time.RFC3339), formatHours(l.ToFirstReview), formatHours(l.ToApproval), formatHours(l.ToMerge)})
+		}
+		if err := out.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+
+		if format != formatNDJSON {
+			fmt.Println()
+		}
+		summary := newRecordWriter(os.Stdout, format, []string{"reviewer", "reviews", "p50_hours_to_first_review", "p90_hours_to_first_review", "p50_hours_to_approval", "p90_hours_to_approval", "p50_hours_to_merge", "p90_hours_to_merge"})
+		for _, s := range summarizeReviewLatencies(latencies) {
+			if user != "" && !strings.EqualFold(user, s.Reviewer) {
+				continue
+			}
+			record := reviewerSummaryRecord{Reviewer: s.Reviewer, Reviews: len(s.ToFirstReview),
+				P50HoursToFirstReview: hours(percentile(s.ToFirstReview, 50)), P90HoursToFirstReview: hours(percentile(s.ToFirstReview, 90)),
+				P50HoursToApproval: hours(percentile(s.ToApproval, 50)), P90HoursToApproval: hours(percentile(s.ToApproval, 90)),
+				P50HoursToMerge: hours(percentile(s.ToMerge, 50)), P90HoursToMerge: hours(percentile(s.ToMerge, 90))}
+			summary.write(record, []string{s.Reviewer, strconv.Itoa(len(s.ToFirstReview)),
+				formatHours(percentile(s.ToFirstReview, 50)), formatHours(percentile(s.ToFirstReview, 90)),
+				formatHours(percentile(s.ToApproval, 50)), formatHours(percentile(s.ToApproval, 90)),
+				formatHours(percentile(s.ToMerge, 50)), formatHours(percentile(s.ToMerge, 90))})
+		}
+		if err := summary.flush(); err != nil {
+			fmt.Println("an error occurred while printing. err:", err)
+			return
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(reviewLatencyCmd)
+	reviewLatencyCmd.Flags().StringP("repo", "R", "", "repo to search for pull requests, owner/repo or a url (default: the remote of the git clone in the working directory)")
+	reviewLatencyCmd.Flags().StringP("user", "U", "", "only print latencies for this reviewer")
+	reviewLatencyCmd.Flags().StringP("start", "S", "", "pull request opened start day")
+	reviewLatencyCmd.Flags().StringP("end", "E", "", "pull request opened end day")
+	addOutputFlags(reviewLatencyCmd)
+	reviewLatencyCmd.MarkFlagRequired("start")
+	reviewLatencyCmd.MarkFlagRequired("end")
+}
+
+// noLatency marks a milestone (first review, approval, merge) that has not happened yet
+const noLatency = time.Duration(-1)
+
+type reviewLatency struct {
+	PullNumber    int
+	Author        string
+	Reviewer      string
+	WaitingSince  time.Time
+	ToFirstReview time.Duration
+	ToApproval    time.Duration
+	ToMerge       time.Duration
+}
+
+// reviewLatencyRecord is how a reviewLatency is printed as json. hours are null until the milestone happened
+type reviewLatencyRecord struct {
+	PullNumber         int       `json:"pull_number"`
+	Author             string    `json:"author"`
+	Reviewer           string    `json:"reviewer"`
+	WaitingSince       time.Time `json:"waiting_since"`
+	HoursToFirstReview *float64  `json:"hours_to_first_review"`
+	HoursToApproval    *float64  `json:"hours_to_approval"`
+	HoursToMerge       *float64  `json:"hours_to_merge"`
+}
+
+type reviewerSummaryRecord struct {
+	Reviewer              string   `json:"reviewer"`
+	Reviews               int      `json:"reviews"`
+	P50HoursToFirstReview *float64 `json:"p50_hours_to_first_review"`
+	P90HoursToFirstReview *float64 `json:"p90_hours_to_first_review"`
+	P50HoursToApproval    *float64 `json:"p50_hours_to_approval"`
+	P90HoursToApproval    *float64 `json:"p90_hours_to_approval"`
+	P50HoursToMerge       *float64 `json:"p50_hours_to_merge"`
+	P90HoursToMerge       *float64 `json:"p90_hours_to_merge"`
+}
+
+type reviewerLatencies struct {
+	Reviewer      string
+	ToFirstReview []time.Duration
+	ToApproval    []time.Duration
+	ToMerge       []time.Duration
+}
+
+// getReviewLatencies returns one row per pull request opened in [startTime, endTime) and reviewer.
+// a reviewer's clock starts when their review was first requested, or when the pull request was opened
+// if they reviewed without being asked.
+func getReviewLatencies(pullRequests []github.PullRequest, reviews []github.PullReview, requests []github.ReviewRequest, startTime, endTime time.Time) []reviewLatency {
+
+	type key struct {
+		number   int
+		reviewer string
+	}
+	requestedAt := make(map[key]time.Time)
+	for _, r := range requests {
+		k := key{r.PullNumber, r.Reviewer}
+		if t, ok := requestedAt[k]; !ok || r.RequestedAt.Before(t) {
+			requestedAt[k] = r.RequestedAt
+		}
+	}
+	firstReviewAt := make(map[key]time.Time)
+	approvedAt := make(map[key]time.Time)
+	for _, r := range reviews {
+		k := key{r.PullNumber, r.Handle}
+		if t, ok := firstReviewAt[k]; !ok || r.SubmittedAt.Before(t) {
+			firstReviewAt[k] = r.SubmittedAt
+		}
+		if r.State == "APPROVED" {
+			if t, ok := approvedAt[k]; !ok || r.SubmittedAt.Before(t) {
+				approvedAt[k] = r.SubmittedAt
+			}
+		}
+	}
+
+	var latencies []reviewLatency
+	for _, pr := range pullRequests {
+		if pr.CreatedAt.Before(startTime) || !pr.CreatedAt.Before(endTime) {
+			continue
+		}
+		reviewers := make(map[string]bool)
+		for k := range requestedAt {
+			if k.number == pr.Number {
+				reviewers[k.reviewer] = true
+			}
+		}
+		for k := range firstReviewAt {
+			if k.number == pr.Number {
+				reviewers[k.reviewer] = true
+			}
+		}
+		delete(reviewers, pr.Handle)
+		var names []string
+		for name := range reviewers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			k := key{pr.Number, name}
+			waitingSince := pr.CreatedAt
+			if t, ok := requestedAt[k]; ok && t.After(waitingSince) {
+				waitingSince = t
+			}
+			latency := reviewLatency{PullNumber: pr.Number, Author: pr.Handle, Reviewer: name, WaitingSince: waitingSince,
+				ToFirstReview: noLatency, ToApproval: noLatency, ToMerge: noLatency}
+			if t, ok := firstReviewAt[k]; ok {
+				latency.ToFirstReview = nonNegative(t.Sub(waitingSince))
+			}
+			if t, ok := approvedAt[k]; ok {
+				latency.ToApproval = nonNegative(t.Sub(waitingSince))
+			}
+			if !pr.MergedAt.IsZero() {
+				latency.ToMerge = nonNegative(pr.MergedAt.Sub(waitingSince))
+			}
+			latencies = append(latencies, latency)
+		}
+	}
+	return latencies
+}
+
+// summarizeReviewLatencies groups latencies by reviewer, in reviewer order
+func summarizeReviewLatencies(latencies []reviewLatency) []reviewerLatencies {
+
+	byReviewer := make(map[string]*reviewerLatencies)
+	var names []string
+	for _, l := range latencies {
+		s, ok := byReviewer[l.Reviewer]
+		if !ok {
+			s = &reviewerLatencies{Reviewer: l.Reviewer}
+			byReviewer[l.Reviewer] = s
+			names = append(names, l.Reviewer)
+		}
+		if l.ToFirstReview != noLatency {
+			s.ToFirstReview = append(s.ToFirstReview, l.ToFirstReview)
+		}
+		if l.ToApproval != noLatency {
+			s.ToApproval = append(s.ToApproval, l.ToApproval)
+		}
+		if l.ToMerge != noLatency {
+			s.ToMerge = append(s.ToMerge, l.ToMerge)
+		}
+	}
+	sort.Strings(names)
+
+	var summaries []reviewerLatencies
+	for _, name := range names {
+		summaries = append(summaries, *byReviewer[name])
+	}
+	return summaries
+}
+
+// percentile returns the nearest-rank p-th percentile of durations, or noLatency when there are none
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return noLatency
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func hours(d time.Duration) *float64 {
+	if d == noLatency {
+		return nil
+	}
+	h := math.Round(d.Hours()*10) / 10
+	return &h
+}
+
+func formatHours(d time.Duration) string {
+	if d == noLatency {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", d.Hours())
+}
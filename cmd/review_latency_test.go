@@ -0,0 +1,82 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{5 * time.Hour, 1 * time.Hour, 3 * time.Hour, 2 * time.Hour, 4 * time.Hour}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0, 1 * time.Hour},
+		{50, 3 * time.Hour},
+		{90, 5 * time.Hour},
+		{100, 5 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := percentile(durations, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if durations[0] != 5*time.Hour {
+		t.Error("percentile sorted its argument")
+	}
+	if got := percentile(nil, 50); got != noLatency {
+		t.Errorf("percentile of nothing = %v, want noLatency", got)
+	}
+}
+
+func TestGetReviewLatencies(t *testing.T) {
+	opened := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	pulls := []github.PullRequest{
+		{Number: 1, Handle: "author", CreatedAt: opened, MergedAt: opened.Add(10 * time.Hour)},
+		// opened after the date range
+		{Number: 2, Handle: "author", CreatedAt: opened.AddDate(0, 1, 0)},
+	}
+	requests := []github.ReviewRequest{
+		{PullNumber: 1, Reviewer: "asked", RequestedAt: opened.Add(2 * time.Hour)},
+	}
+	reviews := []github.PullReview{
+		{PullNumber: 1, Handle: "asked", State: "COMMENTED", SubmittedAt: opened.Add(3 * time.Hour)},
+		{PullNumber: 1, Handle: "asked", State: "APPROVED", SubmittedAt: opened.Add(6 * time.Hour)},
+		{PullNumber: 1, Handle: "unasked", State: "APPROVED", SubmittedAt: opened.Add(1 * time.Hour)},
+		{PullNumber: 1, Handle: "author", State: "COMMENTED", SubmittedAt: opened.Add(1 * time.Hour)},
+		{PullNumber: 2, Handle: "asked", State: "APPROVED", SubmittedAt: opened.AddDate(0, 1, 1)},
+	}
+	latencies := getReviewLatencies(pulls, reviews, requests, opened.AddDate(0, 0, -1), opened.AddDate(0, 0, 1))
+	if len(latencies) != 2 {
+		t.Fatalf("%d latencies, want 2: %+v", len(latencies), latencies)
+	}
+	asked, unasked := latencies[0], latencies[1]
+	if asked.Reviewer != "asked" || asked.ToFirstReview != time.Hour || asked.ToApproval != 4*time.Hour || asked.ToMerge != 8*time.Hour {
+		t.Errorf("asked reviewer's clock starts at the request: %+v", asked)
+	}
+	if unasked.Reviewer != "unasked" || unasked.ToFirstReview != time.Hour || unasked.ToApproval != time.Hour || unasked.ToMerge != 10*time.Hour {
+		t.Errorf("unasked reviewer's clock starts when the pull request was opened: %+v", unasked)
+	}
+}
@@ -21,25 +21,25 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/csv"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"runtime"
-	"time"
+	"strconv"
 
+	"github.com/ctava/github-teamwork/github"
 	"github.com/spf13/cobra"
-
-	chart "github.com/wcharczuk/go-chart"
 )
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-	Use:   "github-teamwork",
-	Short: "a set of commands to foster collaboration on github.com",
-	Long:  fmt.Sprintf(`github-teamwork - a set of commands to get answers to your questions about github.com Version: %s Author: Chris Tava <chris1tava@gmail.com>`, VERSION),
+	Use:               "github-teamwork",
+	Short:             "a set of commands to foster collaboration on github.com",
+	Long:              fmt.Sprintf(`github-teamwork - a set of commands to get answers to your questions about github.com Version: %s Author: Chris Tava <chris1tava@gmail.com>`, VERSION),
+	PersistentPostRun: reportBudget,
 }
 
 func checkError(err error) {
@@ -63,7 +63,123 @@ func init() {
 	if defaultThreads > 2 {
 		defaultThreads = 2
 	}
-	RootCmd.PersistentFlags().IntP("threads", "t", defaultThreads, "number of CPUs. (default value: 1 for single-CPU PC, 2 for others)")
+	RootCmd.PersistentFlags().IntP("threads", "t", defaultThreads, "number of API requests to run at once. (default value: 1 for single-CPU PC, 2 for others)")
+	RootCmd.PersistentFlags().String("tz", "UTC", "time zone days are counted in, e.g. America/New_York or Local. roster members can have their own tz")
+	RootCmd.PersistentFlags().Int("max-requests", 0, "stop after this many API requests. (default value: 0, no limit)")
+	RootCmd.PersistentFlags().String("cache-dir", defaultCacheDir(), "directory to cache API responses in")
+	RootCmd.PersistentFlags().Bool("no-cache", false, "do not cache API responses")
+	RootCmd.PersistentFlags().String("api-url", "", "GitHub Enterprise API url, e.g. https://github.example.com/api/v3/. (default value: GITHUB_API_URL, or the host of --repo)")
+	RootCmd.PersistentFlags().String("upload-url", "", "GitHub Enterprise upload url. (default value: GITHUB_UPLOAD_URL, or the host of --repo)")
+	RootCmd.PersistentFlags().String("ca-bundle", "", "PEM file with extra certificate authorities to trust")
+	RootCmd.PersistentFlags().String("proxy", "", "HTTP proxy url. (default value: HTTPS_PROXY)")
+	RootCmd.PersistentFlags().String("token", "", "access token, or a comma separated pool of tokens used in turn as they hit their rate limit. (default value: GITHUB_ACCESS_TOKEN, gh hosts.yml, ~/.netrc, ~/.git-credentials or .env)")
+	RootCmd.PersistentFlags().String("app-id", "", "GitHub App id to authenticate as instead of GITHUB_ACCESS_TOKEN. (default value: GITHUB_APP_ID)")
+	RootCmd.PersistentFlags().String("app-key", "", "GitHub App private key PEM file. (default value: GITHUB_APP_PRIVATE_KEY)")
+	RootCmd.PersistentFlags().String("installation-id", "", "GitHub App installation id. (default value: GITHUB_APP_INSTALLATION_ID)")
+	RootCmd.PersistentFlags().String("app-token-url", "", "url installation tokens are created at. (default value: <api url>/app/installations/<installation id>/access_tokens)")
+}
+
+// runFetcher is the fetcher of the running command, its API budget is reported once the command is done
+var runFetcher github.Fetcher
+
+// newFetcher returns a github API fetcher for a GitHub App installation or a pool of tokens that runs up to --threads requests at once,
+// stops after --max-requests and caches responses in --cache-dir. the API url is --api-url, GITHUB_API_URL
+// or the GitHub Enterprise host of --repo
+func newFetcher(ctx context.Context, cmd *cobra.Command) github.Fetcher {
+	apiURL, uploadURL := getAPIURLs(cmd)
+	opts := github.Options{Threads: getFlagInt(cmd, "threads"), MaxRequests: getFlagInt(cmd, "max-requests"), CacheDir: getCacheDir(cmd),
+		APIURL: apiURL, UploadURL: uploadURL, CABundle: getFlagString(cmd, "ca-bundle"), Proxy: getFlagString(cmd, "proxy")}
+	app, err := getAppAuth(cmd, &opts)
+	checkError(err)
+	if !app {
+		opts.Tokens, _ = github.ResolveTokens(github.DefaultTokenResolvers(getFlagString(cmd, "token")), apiHost(apiURL))
+		if len(opts.Tokens) == 0 {
+			fmt.Fprintln(os.Stderr, "warning: without a token, you will be limited to 60 calls per hour")
+		}
+	}
+	fetcher, err := github.NewFetcherWithOptions(ctx, opts)
+	checkError(err)
+	runFetcher = fetcher
+	return runFetcher
+}
+
+// getAppAuth sets up GitHub App authentication from --app-id, --app-key and --installation-id, or from
+// GITHUB_APP_ID, GITHUB_APP_PRIVATE_KEY and GITHUB_APP_INSTALLATION_ID. it tells whether an app is configured
+func getAppAuth(cmd *cobra.Command, opts *github.Options) (bool, error) {
+	appID := getFlagString(cmd, "app-id")
+	if appID == "" {
+		appID = os.Getenv("GITHUB_APP_ID")
+	}
+	if appID == "" {
+		return false, nil
+	}
+	keyFile := getFlagString(cmd, "app-key")
+	if keyFile == "" {
+		keyFile = os.Getenv("GITHUB_APP_PRIVATE_KEY")
+	}
+	installationID := getFlagString(cmd, "installation-id")
+	if installationID == "" {
+		installationID = os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	}
+	if keyFile == "" || installationID == "" {
+		return false, errors.New("a github app needs --app-key and --installation-id")
+	}
+
+	var err error
+	if opts.AppID, err = strconv.ParseInt(appID, 10, 64); err != nil {
+		return false, errors.New("--app-id needs to be a number")
+	}
+	if opts.InstallationID, err = strconv.ParseInt(installationID, 10, 64); err != nil {
+		return false, errors.New("--installation-id needs to be a number")
+	}
+	if opts.AppPrivateKey, err = ioutil.ReadFile(keyFile); err != nil {
+		return false, errors.New("Could not read app private key file")
+	}
+	opts.AppTokenURL = getFlagString(cmd, "app-token-url")
+	return true, nil
+}
+
+// apiHost is the host tokens are looked up for, github.com unless the api is a GitHub Enterprise one
+func apiHost(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Hostname() == "" {
+		return "github.com"
+	}
+	return u.Hostname()
+}
+
+// getAPIURLs returns --api-url and --upload-url, falling back to GITHUB_API_URL and GITHUB_UPLOAD_URL
+// and then to the host of an explicit --repo. a remote of the working directory's clone does not pick the
+// api, its host may be an ssh alias. empty urls mean github.com
+func getAPIURLs(cmd *cobra.Command) (string, string) {
+	apiURL := getFlagString(cmd, "api-url")
+	if apiURL == "" {
+		apiURL = os.Getenv("GITHUB_API_URL")
+	}
+	uploadURL := getFlagString(cmd, "upload-url")
+	if uploadURL == "" {
+		uploadURL = os.Getenv("GITHUB_UPLOAD_URL")
+	}
+	if repos := getExplicitRepos(cmd); apiURL == "" && len(repos) > 0 {
+		repoAPIURL, repoUploadURL := github.EnterpriseURLs(repos[0])
+		apiURL = repoAPIURL
+		if uploadURL == "" {
+			uploadURL = repoUploadURL
+		}
+	}
+	return apiURL, uploadURL
+}
+
+// reportBudget prints the API requests the command made to stderr, next to the warnings
+func reportBudget(cmd *cobra.Command, args []string) {
+	if runFetcher == nil {
+		return
+	}
+	budget := runFetcher.Budget()
+	if budget.Requests == 0 {
+		return
+	}
+	fmt.Fprintln(os.Stderr, budget)
 }
 
 func getFlagString(cmd *cobra.Command, flag string) string {
@@ -86,10 +202,6 @@ func getFlagInt(cmd *cobra.Command, flag string) int {
 
 const numberOfCharactersInDate = 10
 
-func daysIn(m time.Month, y int) int {
-	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
-}
-
 func writeDataSetToFile(fileName string, data []byte) error {
 	err := ioutil.WriteFile(fileName, data, os.ModePerm)
 	if err != nil {
@@ -97,170 +209,3 @@ func writeDataSetToFile(fileName string, data []byte) error {
 	}
 	return nil
 }
-
-func getDataSetFromFile(fileName string) (bytes.Buffer, error) {
-	var b bytes.Buffer
-	csvBytes, err := ioutil.ReadFile(fileName)
-	if err != nil {
-		return b, errors.New("Could not read input file")
-	}
-
-	b.Write(csvBytes)
-	return b, nil
-}
-
-func getTimeSeriesDataForTheMonth(startYear, endYear int, startMonth, endMonth time.Month) ([]time.Time, error) {
-
-	//startTime := time.Date(startYear, startMonth, 1, 0, 0, 0, 0, time.UTC)
-	//endTime := time.Date(endYear, endMonth, 1, 0, 0, 0, 0, time.UTC) //not supported
-	var timeSeries []time.Time
-	for i := 1; i <= daysIn(startMonth, startYear); i++ {
-		day := time.Date(startYear, startMonth, i, 0, 0, 0, 0, time.UTC)
-		timeSeries = append(timeSeries, day)
-	}
-	return timeSeries, nil
-}
-
-func getCountDataPerDay(startYear int, startMonth time.Month, dataSetFileName string) ([]float64, error) {
-
-	data, err := getDataSetFromFile(dataSetFileName)
-	if err != nil {
-		return nil, errors.New("Could not get data from file")
-	}
-	reader := csv.NewReader(bytes.NewReader(data.Bytes()))
-	reader.FieldsPerRecord = 1
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, errors.New("Could not read in data records.")
-	}
-
-	countsMap := make(map[int]float64)
-	layout := "2006-01-02"
-	for i := 1; i <= daysIn(startMonth, startYear); i++ {
-		day := time.Date(startYear, startMonth, i, 0, 0, 0, 0, time.UTC)
-		countsMap[day.Day()] = float64(0)
-		for _, each := range records {
-			t, err := time.Parse(layout, each[0][0:numberOfCharactersInDate])
-			if err != nil {
-				return nil, errors.New("Could not parse timestamps")
-			}
-			if day.Equal(t) {
-				dayCount := countsMap[day.Day()]
-				countsMap[day.Day()] = dayCount + 1
-			}
-		}
-	}
-
-	var counts []float64
-	for _, v := range countsMap {
-		counts = append(counts, v)
-	}
-	return counts, nil
-}
-
-func drawChart(startYear, endYear int, startMonth, endMonth time.Month, legend, inputFileName, outputfileName string) error {
-
-	timeSeries, tserr := getTimeSeriesDataForTheMonth(startYear, endYear, startMonth, endMonth)
-	if tserr != nil {
-		return tserr
-	}
-	counts, cerr := getCountDataPerDay(startYear, startMonth, inputFileName)
-	if cerr != nil {
-		return cerr
-	}
-	graph := chart.Chart{
-		XAxis: chart.XAxis{
-			Style: chart.Style{Show: true},
-		},
-		YAxis: chart.YAxis{
-			Style: chart.Style{Show: true},
-		},
-		Background: chart.Style{
-			Padding: chart.Box{
-				Top:  20,
-				Left: 20,
-			},
-		},
-		Series: []chart.Series{
-			chart.TimeSeries{
-				Name:    legend,
-				XValues: timeSeries,
-				YValues: counts,
-			},
-		},
-	}
-
-	graph.Elements = []chart.Renderable{
-		chart.Legend(&graph),
-	}
-	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
-	f, err := os.Create(outputfileName)
-	if err != nil {
-		return err
-	}
-	if _, err := f.Write(buffer.Bytes()); err != nil {
-		f.Close()
-		return err
-	}
-	return f.Close()
-}
-
-func drawChartWithFourLines(startYear, endYear int, startMonth, endMonth time.Month, legend1, legend2, legend3, legend4, input1FileName, input2FileName, input3FileName, input4FileName, outputfileName string) error {
-
-	timeSeries, tserr := getTimeSeriesDataForTheMonth(startYear, endYear, startMonth, endMonth)
-	if tserr != nil {
-		return tserr
-	}
-	counts1, cerr := getCountDataPerDay(startYear, startMonth, input1FileName)
-	if cerr != nil {
-		return cerr
-	}
-	counts2, cerr := getCountDataPerDay(startYear, startMonth, input2FileName)
-	if cerr != nil {
-		return cerr
-	}
-	counts3, cerr := getCountDataPerDay(startYear, startMonth, input3FileName)
-	if cerr != nil {
-		return cerr
-	}
-	counts4, cerr := getCountDataPerDay(startYear, startMonth, input4FileName)
-	if cerr != nil {
-		return cerr
-	}
-	graph := chart.Chart{
-		XAxis: chart.XAxis{
-			Style: chart.Style{Show: true},
-		},
-		YAxis: chart.YAxis{
-			Style: chart.Style{Show: true},
-		},
-		Background: chart.Style{
-			Padding: chart.Box{
-				Top:  20,
-				Left: 20,
-			},
-		},
-		Series: []chart.Series{
-			chart.TimeSeries{Name: legend1, XValues: timeSeries, YValues: counts1},
-			chart.TimeSeries{Name: legend2, XValues: timeSeries, YValues: counts2},
-			chart.TimeSeries{Name: legend3, XValues: timeSeries, YValues: counts3},
-			chart.TimeSeries{Name: legend4, XValues: timeSeries, YValues: counts4},
-		},
-	}
-
-	graph.Elements = []chart.Renderable{
-		chart.Legend(&graph),
-	}
-	buffer := bytes.NewBuffer([]byte{})
-	err := graph.Render(chart.PNG, buffer)
-	f, err := os.Create(outputfileName)
-	if err != nil {
-		return err
-	}
-	if _, err := f.Write(buffer.Bytes()); err != nil {
-		f.Close()
-		return err
-	}
-	return f.Close()
-}
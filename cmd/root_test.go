@@ -0,0 +1,86 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestAPIURLsIgnoreTheCloneRemote runs in a clone whose remote is on an enterprise host: only an
+// explicit -R, --api-url or GITHUB_API_URL points the api there
+func TestAPIURLsIgnoreTheCloneRemote(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "clone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.example.com:o/r.git"}} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("GITHUB_API_URL", os.Getenv("GITHUB_API_URL"))
+	os.Unsetenv("GITHUB_API_URL")
+
+	tests := []struct {
+		args []string
+		api  string
+	}{
+		{nil, ""},
+		{[]string{"-R", "https://github.example.com/o/r"}, "https://github.example.com/api/v3/"},
+		{[]string{"-R", "o/r"}, ""},
+		{[]string{"--api-url", "https://ghe.example.com/api/v3/"}, "https://ghe.example.com/api/v3/"},
+	}
+	for _, test := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("api-url", "", "")
+		cmd.Flags().String("upload-url", "", "")
+		addRepoFlags(cmd, "repo")
+		if err := cmd.ParseFlags(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if api, _ := getAPIURLs(cmd); api != test.api {
+			t.Errorf("%v: api url %q, want %q", test.args, api, test.api)
+		}
+	}
+	// the remote still names the repo a report is on
+	cmd := &cobra.Command{}
+	addRepoFlags(cmd, "repo")
+	if repos := getRepoFlag(cmd); len(repos) != 1 || repos[0] != "https://github.example.com/o/r" {
+		t.Errorf("repos %v, want the remote of the clone", repos)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// roster is the list of team members read from a roster.yaml file. tz is optional, members without one
+// count days in --tz. emails are the commit emails --git knows a member by:
+//
+//	members:
+//	  - handle: octocat
+//	    tz: America/Los_Angeles
+//	    emails: [octocat@example.com]
+//	  - handle: hubot
+type roster struct {
+	Members []rosterMember `yaml:"members"`
+}
+
+type rosterMember struct {
+	Handle string   `yaml:"handle"`
+	TZ     string   `yaml:"tz"`
+	Emails []string `yaml:"emails"`
+}
+
+func readRoster(fileName string) (roster, error) {
+	var r roster
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return r, errors.New("Could not read roster file")
+	}
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return r, err
+	}
+	if len(r.Members) == 0 {
+		return r, errors.New("roster has no members")
+	}
+	return r, nil
+}
+
+func addMemberFlags(cmd *cobra.Command) {
+	cmd.Flags().String("roster", "", "roster.yaml listing the team members to report on (instead of --user)")
+}
+
+// getMembers returns the handles a report covers and a label for its output files.
+// --user wins over --roster, which wins over --team org/slug
+func getMembers(ctx context.Context, cmd *cobra.Command, fetcher github.Fetcher) ([]string, string, error) {
+
+	user := getFlagString(cmd, "user")
+	if user != "" {
+		return []string{user}, user, nil
+	}
+
+	rosterFile := getFlagString(cmd, "roster")
+	if rosterFile != "" {
+		r, err := readRoster(rosterFile)
+		if err != nil {
+			return nil, "", err
+		}
+		var members []string
+		for _, m := range r.Members {
+			members = append(members, m.Handle)
+		}
+		return members, strings.TrimSuffix(filepath.Base(rosterFile), filepath.Ext(rosterFile)), nil
+	}
+
+	team := getFlagString(cmd, "team")
+	if team != "" {
+		values := strings.Split(team, "/")
+		if len(values) < 2 {
+			return nil, "", errors.New("team name needs to be owner/teamname")
+		}
+		members, err := fetcher.FetchTeamMembers(ctx, values[0], values[1])
+		if err != nil {
+			return nil, "", err
+		}
+		if len(members) == 0 {
+			return nil, "", errors.New("team has no members")
+		}
+		return members, values[1], nil
+	}
+
+	return nil, "", errors.New("one of --user, --team or --roster is required")
+}
+
+// getRosterEmails maps the emails of the --roster members to their handles
+func getRosterEmails(cmd *cobra.Command) (map[string]string, error) {
+	handles := make(map[string]string)
+	if cmd.Flags().Lookup("roster") == nil || getFlagString(cmd, "roster") == "" {
+		return handles, nil
+	}
+	r, err := readRoster(getFlagString(cmd, "roster"))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range r.Members {
+		for _, email := range m.Emails {
+			handles[email] = m.Handle
+		}
+	}
+	return handles, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsHandle tells whether handle is one of members. github handles are not case sensitive
+func containsHandle(members []string, handle string) bool {
+	for _, m := range members {
+		if strings.EqualFold(m, handle) {
+			return true
+		}
+	}
+	return false
+}
+
+// memberHandle returns handle spelled the way members has it, so a member gets one chart line whatever
+// the case an api answers with
+func memberHandle(members []string, handle string) string {
+	for _, m := range members {
+		if strings.EqualFold(m, handle) {
+			return m
+		}
+	}
+	return handle
+}
+
+// memberSeriesName names a member's chart line. a single member's line keeps the command's name
+func memberSeriesName(cmdName string, members []string, handle string) string {
+	if len(members) == 1 {
+		return cmdName
+	}
+	return memberHandle(members, handle)
+}
@@ -0,0 +1,67 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandlesIgnoreCase(t *testing.T) {
+	members := []string{"Alice", "bob"}
+	tests := []struct {
+		handle   string
+		contains bool
+		series   string
+	}{
+		{"alice", true, "Alice"},
+		{"ALICE", true, "Alice"},
+		{"Bob", true, "bob"},
+		{"carol", false, "carol"},
+	}
+	for _, test := range tests {
+		if got := containsHandle(members, test.handle); got != test.contains {
+			t.Errorf("containsHandle(%v, %q) = %v, want %v", members, test.handle, got, test.contains)
+		}
+		if got := memberSeriesName("prcomments", members, test.handle); got != test.series {
+			t.Errorf("memberSeriesName(%q) = %q, want %q", test.handle, got, test.series)
+		}
+	}
+	if got := memberSeriesName("prcomments", []string{"Alice"}, "alice"); got != "prcomments" {
+		t.Errorf("a single member's line is %q, want prcomments", got)
+	}
+}
+
+func TestTimeZonesIgnoreHandleCase(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+	zones := timeZones{defaultZone: time.UTC, members: map[string]*time.Location{"alice": tokyo}}
+	// 20:00 UTC is the next day in Tokyo
+	at := time.Date(2018, 1, 2, 20, 0, 0, 0, time.UTC)
+	if day := zones.day("Alice", at); day != "2018-01-03" {
+		t.Errorf("Alice's day %s, want 2018-01-03 in the roster tz", day)
+	}
+	if day := zones.day("bob", at); day != "2018-01-02" {
+		t.Errorf("bob's day %s, want 2018-01-02 in --tz", day)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// chart buckets
+const (
+	bucketDay   = "day"
+	bucketWeek  = "week"
+	bucketMonth = "month"
+)
+
+// bucketStart returns the first day of the bucket t falls in
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case bucketWeek:
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	case bucketMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+func nextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case bucketWeek:
+		return t.AddDate(0, 0, 7)
+	case bucketMonth:
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// bucketSeries is a named count per time bucket. Starts holds the first day of every bucket from
+// the start day through the end day in chronological order and Counts[i] belongs to Starts[i],
+// so two series over the same range and bucket always line up on the X axis.
+type bucketSeries struct {
+	Name   string
+	Bucket string
+	Starts []time.Time
+	Counts []float64
+
+	firstDay time.Time
+	lastDay  time.Time
+}
+
+func newBucketSeries(name string, startTime, endTime time.Time, bucket string) (*bucketSeries, error) {
+
+	if endTime.Before(startTime) {
+		return nil, errors.New("end day is before start day")
+	}
+	s := &bucketSeries{
+		Name:     name,
+		Bucket:   bucket,
+		firstDay: bucketStart(startTime, bucketDay),
+		lastDay:  bucketStart(endTime, bucketDay),
+	}
+	last := bucketStart(endTime, bucket)
+	for t := bucketStart(startTime, bucket); !t.After(last); t = nextBucket(t, bucket) {
+		s.Starts = append(s.Starts, t)
+	}
+	s.Counts = make([]float64, len(s.Starts))
+	return s, nil
+}
+
+// add counts t in its bucket. days outside of the series' range are ignored
+func (s *bucketSeries) add(t time.Time) {
+	day := bucketStart(t, bucketDay)
+	if day.Before(s.firstDay) || day.After(s.lastDay) {
+		return
+	}
+	start := bucketStart(day, s.Bucket)
+	// Starts is sorted, so a binary search finds the bucket
+	lo, hi := 0, len(s.Starts)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if s.Starts[mid].Before(start) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo < len(s.Starts) && s.Starts[lo].Equal(start) {
+		s.Counts[lo]++
+	}
+}
+
+// seriesSet is a list of bucket series over the same range and bucket, kept in the order they were added
+type seriesSet struct {
+	startTime time.Time
+	endTime   time.Time
+	bucket    string
+	series    []*bucketSeries
+}
+
+func newSeriesSet(startTime, endTime time.Time, bucket string) (*seriesSet, error) {
+	if endTime.Before(startTime) {
+		return nil, errors.New("end day is before start day")
+	}
+	return &seriesSet{startTime: startTime, endTime: endTime, bucket: bucket}, nil
+}
+
+// get returns the series called name, adding an empty one if there is none yet
+func (ss *seriesSet) get(name string) *bucketSeries {
+	for _, s := range ss.series {
+		if s.Name == name {
+			return s
+		}
+	}
+	// the range was checked by newSeriesSet
+	s, _ := newBucketSeries(name, ss.startTime, ss.endTime, ss.bucket)
+	ss.series = append(ss.series, s)
+	return s
+}
+
+// addDay counts a YYYY-MM-DD day, or a timestamp starting with one, in the series called name
+func (ss *seriesSet) addDay(name, day string) error {
+	if len(day) < numberOfCharactersInDate {
+		return errors.New("Could not parse timestamps")
+	}
+	t, err := time.Parse("2006-01-02", day[0:numberOfCharactersInDate])
+	if err != nil {
+		return errors.New("Could not parse timestamps")
+	}
+	ss.get(name).add(t)
+	return nil
+}
+
+func (ss *seriesSet) list() []*bucketSeries {
+	return ss.series
+}
+
+// rows returns a header followed by one row per bucket with the bucket start and the count of every series
+func (ss *seriesSet) rows() [][]string {
+	header := []string{"bucket_start"}
+	for _, s := range ss.series {
+		header = append(header, s.Name)
+	}
+	rows := [][]string{header}
+	if len(ss.series) > 0 {
+		for i, start := range ss.series[0].Starts {
+			row := []string{start.Format("2006-01-02")}
+			for _, s := range ss.series {
+				row = append(row, strconv.FormatFloat(s.Counts[i], 'f', -1, 64))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (ss *seriesSet) csv() []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.WriteAll(ss.rows())
+	return b.Bytes()
+}
@@ -0,0 +1,142 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBucketStart(t *testing.T) {
+	tests := []struct {
+		t      string
+		bucket string
+		want   string
+	}{
+		{"2018-03-14", bucketDay, "2018-03-14"},
+		{"2018-03-14", bucketWeek, "2018-03-12"},
+		{"2018-03-12", bucketWeek, "2018-03-12"},
+		{"2018-03-18", bucketWeek, "2018-03-12"},
+		{"2018-03-14", bucketMonth, "2018-03-01"},
+		// weeks and months across a year boundary
+		{"2019-01-01", bucketWeek, "2018-12-31"},
+		{"2021-01-03", bucketWeek, "2020-12-28"},
+		{"2019-01-31", bucketMonth, "2019-01-01"},
+	}
+	for _, tt := range tests {
+		if got := bucketStart(day(tt.t), tt.bucket).Format("2006-01-02"); got != tt.want {
+			t.Errorf("bucketStart(%s, %s) = %s, want %s", tt.t, tt.bucket, got, tt.want)
+		}
+	}
+}
+
+func TestNewBucketSeries(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		bucket string
+		starts []string
+	}{
+		{"days across a month", "2018-01-30", "2018-02-02", bucketDay, []string{"2018-01-30", "2018-01-31", "2018-02-01", "2018-02-02"}},
+		{"days across a year", "2018-12-31", "2019-01-01", bucketDay, []string{"2018-12-31", "2019-01-01"}},
+		{"leap day", "2020-02-28", "2020-03-01", bucketDay, []string{"2020-02-28", "2020-02-29", "2020-03-01"}},
+		{"weeks across a year", "2018-12-20", "2019-01-09", bucketWeek, []string{"2018-12-17", "2018-12-24", "2018-12-31", "2019-01-07"}},
+		{"months across a year", "2018-11-15", "2019-02-01", bucketMonth, []string{"2018-11-01", "2018-12-01", "2019-01-01", "2019-02-01"}},
+		{"one day", "2018-05-05", "2018-05-05", bucketMonth, []string{"2018-05-01"}},
+	}
+	for _, tt := range tests {
+		s, err := newBucketSeries(tt.name, day(tt.start), day(tt.end), tt.bucket)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(s.Starts) != len(tt.starts) || len(s.Counts) != len(tt.starts) {
+			t.Fatalf("%s: %d starts and %d counts, want %d", tt.name, len(s.Starts), len(s.Counts), len(tt.starts))
+		}
+		for i, start := range s.Starts {
+			if got := start.Format("2006-01-02"); got != tt.starts[i] {
+				t.Errorf("%s: start %d = %s, want %s", tt.name, i, got, tt.starts[i])
+			}
+		}
+	}
+
+	if _, err := newBucketSeries("backwards", day("2018-02-01"), day("2018-01-01"), bucketDay); err == nil {
+		t.Error("an end day before the start day should fail")
+	}
+}
+
+func TestBucketSeriesAdd(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  string
+		end    string
+		bucket string
+		days   []string
+		counts []float64
+	}{
+		{"days", "2018-01-30", "2018-02-02", bucketDay,
+			[]string{"2018-01-30", "2018-02-01", "2018-02-01", "2018-01-29", "2018-02-03"}, []float64{1, 0, 2, 0}},
+		{"weeks across a year", "2018-12-24", "2019-01-13", bucketWeek,
+			[]string{"2018-12-30", "2018-12-31", "2019-01-01", "2019-01-06", "2019-01-13"}, []float64{1, 3, 1}},
+		// days before the start day are left out even when they fall in the first bucket
+		{"months across a year", "2018-12-15", "2019-02-10", bucketMonth,
+			[]string{"2018-12-01", "2018-12-15", "2018-12-31", "2019-01-01", "2019-02-10", "2019-02-11"}, []float64{2, 1, 1}},
+	}
+	for _, tt := range tests {
+		s, err := newBucketSeries(tt.name, day(tt.start), day(tt.end), tt.bucket)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, d := range tt.days {
+			s.add(day(d))
+		}
+		for i, want := range tt.counts {
+			if s.Counts[i] != want {
+				t.Errorf("%s: count of %s = %v, want %v", tt.name, s.Starts[i].Format("2006-01-02"), s.Counts[i], want)
+			}
+		}
+	}
+}
+
+func TestSeriesSetRows(t *testing.T) {
+	ss, err := newSeriesSet(day("2018-01-01"), day("2018-01-02"), bucketDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss.get("b")
+	ss.addDay("a", "2018-01-02T10:00:00Z")
+	ss.addDay("b", "2018-01-01")
+	if err := ss.addDay("a", "2018"); err == nil {
+		t.Error("a short day should fail")
+	}
+	want := "bucket_start,b,a\n2018-01-01,1,0\n2018-01-02,0,1\n"
+	if got := string(ss.csv()); got != want {
+		t.Errorf("csv = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/gitlog"
+	"github.com/ctava/github-teamwork/store"
+	"github.com/spf13/cobra"
+)
+
+const defaultStoreFile = "github-teamwork.db"
+
+// errNotStored is returned by the offline fetcher for data that sync does not store
+var errNotStored = errors.New("not available offline, the local store only holds pull request comments, repo events and team discussions")
+
+func addStoreFlags(cmd *cobra.Command) {
+	cmd.Flags().String("store", defaultStoreFile, "local store written by the sync command")
+	cmd.Flags().Bool("offline", false, "read from the local store instead of the github API")
+}
+
+// newReportFetcher returns a fetcher for the github API, for the local store with --offline or for
+// the history of a local clone with --git
+func newReportFetcher(ctx context.Context, cmd *cobra.Command) github.Fetcher {
+	if gitDir := getGitDir(cmd); gitDir != "" {
+		handles, err := getRosterEmails(cmd)
+		checkError(err)
+		runFetcher = gitlog.NewFetcher(gitDir, handles)
+		return runFetcher
+	}
+	if getFlagBool(cmd, "offline") {
+		runFetcher = &storeFetcher{path: getFlagString(cmd, "store")}
+		return runFetcher
+	}
+	return newFetcher(ctx, cmd)
+}
+
+func addGitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("git", "", "read commits, merges and Co-authored-by trailers from the local clone in this directory instead of the github API")
+}
+
+// getGitDir returns --git, "" for commands without it
+func getGitDir(cmd *cobra.Command) string {
+	if cmd.Flags().Lookup("git") == nil {
+		return ""
+	}
+	return getFlagString(cmd, "git")
+}
+
+// isLocal tells whether cmd reads the local store or a local clone, which are not limited like the events api
+func isLocal(cmd *cobra.Command) bool {
+	return getFlagBool(cmd, "offline") || getGitDir(cmd) != ""
+}
+
+// repoKey is the lowercase owner/repo name a repository is stored and looked up under, github names are
+// not case sensitive
+func repoKey(repository string) string {
+	ref, err := github.ParseRepoRef(repository)
+	if err != nil {
+		return strings.ToLower(repository)
+	}
+	return strings.ToLower(ref.String())
+}
+
+// storeFetcher answers fetches from the local store, so a report runs without the network
+type storeFetcher struct {
+	path string
+}
+
+func (s *storeFetcher) open() (*store.Store, error) {
+	return store.Open(s.path)
+}
+
+// ExpandRepos matches repo globs against the repos in the store. topics are not stored
+func (s *storeFetcher) ExpandRepos(ctx context.Context, patterns []string, topic string) ([]string, error) {
+	if topic != "" {
+		return nil, errors.New("--topic is " + errNotStored.Error())
+	}
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	stored, err := db.Repos()
+	if err != nil {
+		return nil, err
+	}
+	var repos []string
+	for _, pattern := range patterns {
+		key := repoKey(pattern)
+		if !strings.ContainsAny(key, "*?[") {
+			repos = append(repos, key)
+			continue
+		}
+		for _, r := range stored {
+			if ok, _ := path.Match(key, repoKey(r)); ok && !containsString(repos, r) {
+				repos = append(repos, r)
+			}
+		}
+	}
+	if len(repos) == 0 {
+		return nil, errors.New("no repository in the local store matches " + strings.Join(patterns, ", "))
+	}
+	return repos, nil
+}
+
+// Budget is empty, the store makes no API requests
+func (s *storeFetcher) Budget() github.Budget {
+	return github.Budget{}
+}
+
+func (s *storeFetcher) FetchPullRequestComments(ctx context.Context, repositoryURL string) ([]github.PullComment, error) {
+	return s.FetchPullRequestCommentsSince(ctx, repositoryURL, time.Time{})
+}
+
+func (s *storeFetcher) FetchPullRequestCommentsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullComment, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.PullComments(repoKey(repositoryURL))
+}
+
+func (s *storeFetcher) FetchPullRequests(ctx context.Context, repositoryURL string) ([]github.PullRequest, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchPullRequestsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullRequest, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchPullRequestReviews(ctx context.Context, repositoryURL string) ([]github.PullReview, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchPullRequestReviewsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullReview, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchPullRequestReviewsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]github.PullReview, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchReviewRequestsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]github.ReviewRequest, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchIssueComments(ctx context.Context, repositoryURL string) ([]github.IssueComment, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchRepoEvents(ctx context.Context, repositoryURL string) ([]github.RepoEvent, error) {
+	return s.FetchRepoEventsSince(ctx, repositoryURL, "")
+}
+
+// FetchRepoEventsSince returns the stored events newest first, the order of the events api
+func (s *storeFetcher) FetchRepoEventsSince(ctx context.Context, repositoryURL string, sinceID string) ([]github.RepoEvent, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	events, err := db.RepoEvents(repoKey(repositoryURL))
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+func (s *storeFetcher) FetchRepoActivity(ctx context.Context, repositoryURL string, since, until time.Time) ([]github.RepoEvent, error) {
+	return nil, errNotStored
+}
+
+func (s *storeFetcher) FetchTeamDiscussionComments(ctx context.Context, org, teamName string) ([]github.DiscussionComment, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.DiscussionComments(org + "/" + teamName)
+}
+
+func (s *storeFetcher) FetchTeamMembers(ctx context.Context, org, teamName string) ([]string, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	members, err := db.TeamMembers(org + "/" + teamName)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, errors.New("team " + org + "/" + teamName + " is not in the local store, sync it with --team first")
+	}
+	return members, nil
+}
@@ -0,0 +1,149 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/store"
+)
+
+func TestRepoKey(t *testing.T) {
+	tests := []struct {
+		repository string
+		want       string
+	}{
+		{"Owner/Repo", "owner/repo"},
+		{"https://github.com/Owner/Repo.git", "owner/repo"},
+		{"git@github.com:Owner/Repo.git", "owner/repo"},
+		{"Owner/*", "owner/*"},
+	}
+	for _, test := range tests {
+		if got := repoKey(test.repository); got != test.want {
+			t.Errorf("repoKey(%q) = %q, want %q", test.repository, got, test.want)
+		}
+	}
+}
+
+func TestMatchRepo(t *testing.T) {
+	patterns := []string{repoKey("Owner/Repo"), repoKey("Other/*")}
+	tests := []struct {
+		repo string
+		want bool
+	}{
+		{"owner/repo", true},
+		{"OWNER/REPO", true},
+		{"other/Tools", true},
+		{"owner/repo2", false},
+	}
+	for _, test := range tests {
+		if got := matchRepo(patterns, test.repo); got != test.want {
+			t.Errorf("matchRepo(%v, %q) = %v, want %v", patterns, test.repo, got, test.want)
+		}
+	}
+}
+
+// TestKeepArchiveEvent filters the events of the fixture dump the way import gharchive does
+func TestKeepArchiveEvent(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		members  []string
+		want     []string
+	}{
+		{[]string{repoKey("acme/*")}, nil, []string{"7044401123", "7044401124", "7044401125"}},
+		{[]string{repoKey("Acme/API")}, nil, []string{"7044401123", "7044401125"}},
+		{nil, []string{"alice"}, []string{"7044401123", "7044401126"}},
+		{[]string{repoKey("acme/*")}, []string{"ALICE", "bob"}, []string{"7044401123", "7044401124"}},
+		{[]string{repoKey("nobody/*")}, []string{"alice"}, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		err := readArchiveFile("../github/testdata/2018-01-01-15.json.gz", func(e github.RepoEvent) error {
+			if keepArchiveEvent(test.patterns, test.members, e) {
+				if e.Source != github.SourceGHArchive {
+					t.Errorf("event %s source %q, want %q", e.ID, e.Source, github.SourceGHArchive)
+				}
+				got = append(got, e.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("patterns %v members %v kept %v, want %v", test.patterns, test.members, got, test.want)
+		}
+	}
+}
+
+// TestImportTakesNoRepoFromTheClone runs in a git clone, import must still read all repos without -R
+func TestImportTakesNoRepoFromTheClone(t *testing.T) {
+	if repos := getExplicitRepos(importGHArchiveCmd); len(repos) != 0 {
+		t.Errorf("import repos without -R = %v, want none", repos)
+	}
+}
+
+// TestStoreFetcherIgnoresCase stores events the way import does, with the repo in the case of the
+// archive, and reads them back with -R typed in another case
+func TestStoreFetcherIgnoresCase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.db")
+
+	db, err := store.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := github.RepoEvent{ID: "1", Handle: "alice", Repo: "Owner/Repo", Type: "PushEvent", CreatedAt: time.Date(2018, 1, 2, 0, 0, 0, 0, time.UTC)}
+	if err := db.SaveRepoEvents(repoKey(event.Repo), []github.RepoEvent{event}); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	f := &storeFetcher{path: path}
+	ctx := context.Background()
+	for _, repository := range []string{"owner/repo", "OWNER/Repo", "https://github.com/Owner/REPO"} {
+		events, err := f.FetchRepoEvents(ctx, repository)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 1 || events[0].ID != "1" {
+			t.Errorf("FetchRepoEvents(%q) = %v, want the stored event", repository, events)
+		}
+	}
+	repos, err := f.ExpandRepos(ctx, []string{"OWNER/*"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0] != "owner/repo" {
+		t.Errorf("ExpandRepos = %v, want [owner/repo]", repos)
+	}
+}
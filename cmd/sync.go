@@ -0,0 +1,157 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+	"github.com/ctava/github-teamwork/store"
+	"github.com/spf13/cobra"
+)
+
+var syncCmdName = "sync"
+
+// syncCmd copies github data into the local store, fetching only what changed since the last sync
+var syncCmd = &cobra.Command{
+	Use:   syncCmdName,
+	Short: syncCmdName + " [repo] [team]",
+	Long:  syncCmdName + ` [repo] [team]: stores pull request comments and events of a repo, and the members and discussion comments of a team, in a local database. later syncs only fetch what is new. reports read the database with --offline`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		repo := getFlagString(cmd, "repo")
+		team := getFlagString(cmd, "team")
+		if repo == "" && team == "" {
+			fmt.Println("error: sync needs a --repo, a --team or both")
+			return
+		}
+
+		ctx := context.Background()
+		fetcher := newFetcher(ctx, cmd)
+
+		db, err := store.Open(getFlagString(cmd, "store"))
+		if err != nil {
+			fmt.Println("an error occurred while opening the store. err:", err)
+			return
+		}
+		defer db.Close()
+
+		if repo != "" {
+			if err := syncRepo(ctx, fetcher, db, repo); err != nil {
+				fmt.Println("an error occurred while syncing", repo, "err:", err)
+				return
+			}
+		}
+		if team != "" {
+			if err := syncTeam(ctx, fetcher, db, team); err != nil {
+				fmt.Println("an error occurred while syncing", team, "err:", err)
+				return
+			}
+		}
+	},
+}
+
+// syncRepo fetches pull request comments updated since the last sync and events newer than the
+// newest stored event. the marks are only moved once the data is stored
+func syncRepo(ctx context.Context, fetcher github.Fetcher, db *store.Store, repo string) error {
+	key := repoKey(repo)
+
+	commentsMark := "pullcomments/" + key
+	mark, err := db.Mark(commentsMark)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if mark != "" {
+		if since, err = time.Parse(time.RFC3339, mark); err != nil {
+			return err
+		}
+	}
+	syncStart := time.Now().UTC()
+	comments, err := fetcher.FetchPullRequestCommentsSince(ctx, repo, since)
+	if err != nil {
+		return err
+	}
+	if err := db.SavePullComments(key, comments); err != nil {
+		return err
+	}
+	if err := db.SetMark(commentsMark, syncStart.Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	eventsMark := "repoevents/" + key
+	sinceID, err := db.Mark(eventsMark)
+	if err != nil {
+		return err
+	}
+	events, err := fetcher.FetchRepoEventsSince(ctx, repo, sinceID)
+	if err != nil {
+		return err
+	}
+	if err := db.SaveRepoEvents(key, events); err != nil {
+		return err
+	}
+	// the events api lists the newest event first
+	if len(events) > 0 {
+		if err := db.SetMark(eventsMark, events[0].ID); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%s: %d pull request comments, %d events\n", key, len(comments), len(events))
+	return nil
+}
+
+// syncTeam refetches the members and discussion comments of team, the team discussion api has no since parameter
+func syncTeam(ctx context.Context, fetcher github.Fetcher, db *store.Store, team string) error {
+	values := strings.Split(team, "/")
+	if len(values) < 2 {
+		return fmt.Errorf("team name needs to be owner/teamname")
+	}
+	org, teamName := values[0], values[1]
+
+	members, err := fetcher.FetchTeamMembers(ctx, org, teamName)
+	if err != nil {
+		return err
+	}
+	team = org + "/" + teamName
+	if err := db.SaveTeamMembers(team, members); err != nil {
+		return err
+	}
+	comments, err := fetcher.FetchTeamDiscussionComments(ctx, org, teamName)
+	if err != nil {
+		return err
+	}
+	if err := db.SaveDiscussionComments(team, comments); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d members, %d discussion comments\n", team, len(members), len(comments))
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringP("repo", "R", "", "repo whose pull request comments and events to store")
+	syncCmd.Flags().StringP("team", "T", "", "org/team whose members and discussion comments to store")
+	syncCmd.Flags().String("store", defaultStoreFile, "local store to write")
+}
@@ -0,0 +1,77 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// timeZones the time zones days are counted in: a member's tz from the roster, else --tz. members are
+// keyed by their lowercase handle
+type timeZones struct {
+	defaultZone *time.Location
+	members     map[string]*time.Location
+}
+
+// getTimeZones loads --tz and the tz of every --roster member that has one
+func getTimeZones(cmd *cobra.Command) (timeZones, error) {
+	zones := timeZones{members: make(map[string]*time.Location)}
+	var err error
+	if zones.defaultZone, err = time.LoadLocation(getFlagString(cmd, "tz")); err != nil {
+		return zones, err
+	}
+	if cmd.Flags().Lookup("roster") == nil || getFlagString(cmd, "roster") == "" {
+		return zones, nil
+	}
+	r, err := readRoster(getFlagString(cmd, "roster"))
+	if err != nil {
+		return zones, err
+	}
+	for _, m := range r.Members {
+		if m.TZ == "" {
+			continue
+		}
+		if zones.members[strings.ToLower(m.Handle)], err = time.LoadLocation(m.TZ); err != nil {
+			return zones, err
+		}
+	}
+	return zones, nil
+}
+
+func (z timeZones) zone(handle string) *time.Location {
+	if loc, ok := z.members[strings.ToLower(handle)]; ok {
+		return loc
+	}
+	return z.defaultZone
+}
+
+// day is the calendar day t falls on for handle
+func (z timeZones) day(handle string, t time.Time) string {
+	return t.In(z.zone(handle)).Format("2006-01-02")
+}
+
+// timestamp is t in the time zone of handle
+func (z timeZones) timestamp(handle string, t time.Time) string {
+	return t.In(z.zone(handle)).Format(time.RFC3339)
+}
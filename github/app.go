@@ -0,0 +1,152 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// appTokenSource authenticates as a GitHub App installation. it signs a short lived JWT with the app's
+// private key and trades it for an installation token at tokenURL. wrapped in oauth2.ReuseTokenSource
+// a new installation token is fetched shortly before the last one expires, so long runs keep going
+type appTokenSource struct {
+	appID    int64
+	key      *rsa.PrivateKey
+	tokenURL string
+	client   *http.Client
+}
+
+// installationTokenURL is where an installation token is created on the API at apiURL
+func installationTokenURL(apiURL string, installationID int64) string {
+	if apiURL == "" {
+		apiURL = "https://api.github.com/"
+	}
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	return fmt.Sprintf("%sapp/installations/%d/access_tokens", apiURL, installationID)
+}
+
+func newAppTokenSource(appID int64, privateKeyPEM []byte, tokenURL string, client *http.Client) (oauth2.TokenSource, error) {
+	key, err := parseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	ts := &appTokenSource{appID: appID, key: key, tokenURL: tokenURL, client: client}
+	return oauth2.ReuseTokenSource(nil, ts), nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not create an installation token: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &installationToken); err != nil {
+		return nil, err
+	}
+	if installationToken.Token == "" {
+		return nil, errors.New("could not create an installation token: the response has no token")
+	}
+	// installation tokens live an hour. refreshing a minute early keeps requests in flight valid
+	return &oauth2.Token{AccessToken: installationToken.Token, TokenType: "token",
+		Expiry: installationToken.ExpiresAt.Add(-time.Minute)}, nil
+}
+
+// jwt returns the RS256 signed JSON Web Token the app authenticates with. github accepts at most ten minutes
+// of lifetime, iat is backdated a minute for clock drift
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appID,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey reads the PKCS#1 key github hands out for apps, or a PKCS#8 one
+func parseRSAPrivateKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("the app private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the app private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,195 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTokenEndpoint hands out installation tokens ghs_1, ghs_2, ... that expire after the next lifetime,
+// and checks the JWT of every request
+type fakeTokenEndpoint struct {
+	t         *testing.T
+	key       *rsa.PublicKey
+	appID     int64
+	mu        sync.Mutex
+	requests  int
+	lifetimes []time.Duration
+	status    int
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if r.Method != http.MethodPost {
+		f.t.Errorf("token request method %s, want POST", r.Method)
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		f.t.Fatalf("Authorization %q, want Bearer <jwt>", auth)
+	}
+	f.checkJWT(strings.TrimPrefix(auth, "Bearer "))
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+		return
+	}
+	lifetime := time.Hour
+	if len(f.lifetimes) > 0 {
+		lifetime, f.lifetimes = f.lifetimes[0], f.lifetimes[1:]
+	}
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, f.requests, time.Now().Add(lifetime).UTC().Format(time.RFC3339))
+}
+
+func (f *fakeTokenEndpoint) checkJWT(jwt string) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		f.t.Fatalf("jwt %q has %d parts, want 3", jwt, len(parts))
+	}
+	var header map[string]string
+	decodeJWTPart(f.t, parts[0], &header)
+	if header["alg"] != "RS256" || header["typ"] != "JWT" {
+		f.t.Errorf("jwt header %v, want RS256 JWT", header)
+	}
+	var claims map[string]int64
+	decodeJWTPart(f.t, parts[1], &claims)
+	now := time.Now().Unix()
+	if claims["iss"] != f.appID {
+		f.t.Errorf("iss %d, want %d", claims["iss"], f.appID)
+	}
+	if claims["iat"] >= now {
+		f.t.Errorf("iat %d is not backdated from %d", claims["iat"], now)
+	}
+	if claims["exp"] <= now || claims["exp"]-claims["iat"] > 10*60 || claims["exp"]-now > 10*60 {
+		f.t.Errorf("exp %d, want at most 10 minutes after iat %d and now %d", claims["exp"], claims["iat"], now)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, hash[:], signature); err != nil {
+		f.t.Errorf("jwt signature: %v", err)
+	}
+}
+
+func decodeJWTPart(t *testing.T, part string, v interface{}) {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func TestAppTokenSourceRefreshesExpiredTokens(t *testing.T) {
+	key, keyPEM := newTestAppKey(t)
+	// the first token expires within the minute newAppTokenSource refreshes early, the second lasts an hour
+	endpoint := &fakeTokenEndpoint{t: t, key: &key.PublicKey, appID: 42, lifetimes: []time.Duration{30 * time.Second, time.Hour}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	ts, err := newAppTokenSource(42, keyPEM, server.URL+"/app/installations/7/access_tokens", server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"ghs_1", "ghs_2", "ghs_2"} {
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != want {
+			t.Errorf("token %d is %s, want %s", i, token.AccessToken, want)
+		}
+	}
+	if endpoint.requests != 2 {
+		t.Errorf("%d token requests, want 2", endpoint.requests)
+	}
+}
+
+func TestAppTokenSourceError(t *testing.T) {
+	key, keyPEM := newTestAppKey(t)
+	endpoint := &fakeTokenEndpoint{t: t, key: &key.PublicKey, appID: 42, status: http.StatusUnauthorized}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	ts, err := newAppTokenSource(42, keyPEM, server.URL, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ts.Token()
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("err %v, want the 401 status and message", err)
+	}
+}
+
+func TestFetcherAuthenticatesAsAppInstallation(t *testing.T) {
+	key, keyPEM := newTestAppKey(t)
+	endpoint := &fakeTokenEndpoint{t: t, key: &key.PublicKey, appID: 42}
+	mux := http.NewServeMux()
+	mux.Handle("/api/v3/app/installations/7/access_tokens", endpoint)
+	mux.HandleFunc("/api/v3/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); !strings.HasSuffix(auth, " ghs_1") {
+			t.Errorf("api request Authorization %q, want the installation token", auth)
+		}
+		fmt.Fprint(w, `[{"number":1,"user":{"login":"a"}}]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{AppID: 42, AppPrivateKey: keyPEM, InstallationID: 7, APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulls, err := f.FetchPullRequests(context.Background(), "o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulls) != 1 || endpoint.requests != 1 {
+		t.Errorf("%d pulls and %d token requests, want 1 and 1", len(pulls), endpoint.requests)
+	}
+}
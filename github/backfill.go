@@ -0,0 +1,274 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// sources a RepoEvent can come from
+const (
+	SourceEvents  = "events"
+	SourceCommits = "commits"
+	SourcePulls   = "pulls"
+	SourceIssues  = "issues"
+	SourceReviews = "reviews"
+)
+
+// the events api only lists the events of about the last 90 days, and at most 300 of them
+const (
+	EventsAPIWindow = 90 * 24 * time.Hour
+	EventsAPILimit  = 300
+)
+
+// FetchRepoActivity rebuilds the events of a repo between since and until from durable endpoints, for
+// windows the events api no longer serves: commits become a PushEvent per pusher and day, opened and merged
+// pull requests PullRequestEvents, opened and closed issues IssuesEvents and submitted reviews
+// PullRequestReviewEvents. branch creations are not rebuilt, github keeps neither when nor by whom a branch
+// was created. pull requests and issues are found with the search api, which returns at most 1000 of each
+// per query. events are newest first
+func (s *fetcher) FetchRepoActivity(ctx context.Context, repositoryURL string, since, until time.Time) ([]RepoEvent, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []RepoEvent
+	for _, fetch := range []func(context.Context, RepoRef, time.Time, time.Time) ([]RepoEvent, error){
+		s.fetchCommitActivity, s.fetchPullActivity, s.fetchIssueActivity, s.fetchReviewActivity,
+	} {
+		sourceEvents, err := fetch(ctx, ref, since, until)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, sourceEvents...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.After(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func inWindow(t, since, until time.Time) bool {
+	return !t.Before(since) && t.Before(until)
+}
+
+// fetchCommitActivity groups the commits of the default branch into a PushEvent per pusher and day, the
+// events api has one per push with its number of commits. the pusher is the committer, or the author of
+// commits github committed (web-flow), like merges in the web ui. commits without a linked github login
+// are left out, a display name is not a handle
+func (s *fetcher) fetchCommitActivity(ctx context.Context, ref RepoRef, since, until time.Time) ([]RepoEvent, error) {
+
+	listOpts := github.CommitsListOptions{Since: since, Until: until, ListOptions: github.ListOptions{PerPage: 100}}
+
+	var events []RepoEvent
+	pushes := make(map[string]int)
+	for {
+		commits, resp, err := s.client.Repositories.ListCommits(ctx, ref.Owner, ref.Name, &listOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			handle := c.GetCommitter().GetLogin()
+			if handle == "" || handle == "web-flow" {
+				handle = c.GetAuthor().GetLogin()
+			}
+			if handle == "" {
+				continue
+			}
+			committedAt := c.GetCommit().GetCommitter().GetDate()
+			id := "push:" + handle + ":" + committedAt.UTC().Format("2006-01-02")
+			if i, ok := pushes[id]; ok {
+				events[i].Commits++
+				if committedAt.After(events[i].CreatedAt) {
+					events[i].CreatedAt = committedAt
+				}
+				continue
+			}
+			pushes[id] = len(events)
+			events = append(events, RepoEvent{ID: id, Handle: handle, Repo: ref.String(),
+				Type: "PushEvent", Commits: 1, Source: SourceCommits, CreatedAt: committedAt})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	return events, nil
+}
+
+// searchIssues returns the issues and pull requests of ref matching query
+func (s *fetcher) searchIssues(ctx context.Context, ref RepoRef, query string) ([]github.Issue, error) {
+
+	searchOpts := github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+
+	var issues []github.Issue
+	for {
+		result, resp, err := s.client.Search.Issues(ctx, "repo:"+ref.String()+" "+query, &searchOpts)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, result.Issues...)
+		if resp.NextPage == 0 {
+			break
+		}
+		searchOpts.Page = resp.NextPage
+	}
+	return issues, nil
+}
+
+// searchRange a search qualifier value for the days from since up to until
+func searchRange(since, until time.Time) string {
+	return since.UTC().Format("2006-01-02") + ".." + until.UTC().Add(-time.Nanosecond).Format("2006-01-02")
+}
+
+func (s *fetcher) fetchPullActivity(ctx context.Context, ref RepoRef, since, until time.Time) ([]RepoEvent, error) {
+
+	opened, err := s.searchIssues(ctx, ref, "is:pr created:"+searchRange(since, until))
+	if err != nil {
+		return nil, err
+	}
+	var events []RepoEvent
+	for _, pr := range opened {
+		if inWindow(pr.GetCreatedAt(), since, until) {
+			events = append(events, RepoEvent{ID: "pull:" + strconv.Itoa(pr.GetNumber()) + ":opened", Handle: pr.GetUser().GetLogin(),
+				Repo: ref.String(), Type: "PullRequestEvent", Action: "opened", Number: pr.GetNumber(), Source: SourcePulls, CreatedAt: pr.GetCreatedAt()})
+		}
+	}
+
+	merged, err := s.searchIssues(ctx, ref, "is:pr is:merged merged:"+searchRange(since, until))
+	if err != nil {
+		return nil, err
+	}
+	// search results do not say who merged a pull request
+	mergedEvents := make([]RepoEvent, len(merged))
+	err = s.forEach(len(merged), func(i int) error {
+		pr, _, err := s.client.PullRequests.Get(ctx, ref.Owner, ref.Name, merged[i].GetNumber())
+		if err != nil {
+			return err
+		}
+		mergedEvents[i] = RepoEvent{ID: "pull:" + strconv.Itoa(pr.GetNumber()) + ":merged", Handle: pr.GetMergedBy().GetLogin(),
+			Repo: ref.String(), Type: "PullRequestEvent", Action: "closed", Number: pr.GetNumber(), Merged: true, Source: SourcePulls, CreatedAt: pr.GetMergedAt()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range mergedEvents {
+		if inWindow(e.CreatedAt, since, until) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (s *fetcher) fetchIssueActivity(ctx context.Context, ref RepoRef, since, until time.Time) ([]RepoEvent, error) {
+
+	opened, err := s.searchIssues(ctx, ref, "is:issue created:"+searchRange(since, until))
+	if err != nil {
+		return nil, err
+	}
+	var events []RepoEvent
+	for _, issue := range opened {
+		if inWindow(issue.GetCreatedAt(), since, until) {
+			events = append(events, RepoEvent{ID: "issue:" + strconv.Itoa(issue.GetNumber()) + ":opened", Handle: issue.GetUser().GetLogin(),
+				Repo: ref.String(), Type: "IssuesEvent", Action: "opened", Number: issue.GetNumber(), Source: SourceIssues, CreatedAt: issue.GetCreatedAt()})
+		}
+	}
+
+	closed, err := s.searchIssues(ctx, ref, "is:issue closed:"+searchRange(since, until))
+	if err != nil {
+		return nil, err
+	}
+	// search results do not say who closed an issue
+	closedEvents := make([]RepoEvent, len(closed))
+	err = s.forEach(len(closed), func(i int) error {
+		issue, _, err := s.client.Issues.Get(ctx, ref.Owner, ref.Name, closed[i].GetNumber())
+		if err != nil {
+			return err
+		}
+		closedEvents[i] = RepoEvent{ID: "issue:" + strconv.Itoa(issue.GetNumber()) + ":closed", Handle: issue.GetClosedBy().GetLogin(),
+			Repo: ref.String(), Type: "IssuesEvent", Action: "closed", Number: issue.GetNumber(), Source: SourceIssues, CreatedAt: issue.GetClosedAt()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range closedEvents {
+		if inWindow(e.CreatedAt, since, until) {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// fetchReviewActivity lists the reviews of the pull requests updated since the window started
+func (s *fetcher) fetchReviewActivity(ctx context.Context, ref RepoRef, since, until time.Time) ([]RepoEvent, error) {
+
+	pulls, err := s.searchIssues(ctx, ref, "is:pr updated:>="+since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	eventsByPull := make([][]RepoEvent, len(pulls))
+	err = s.forEach(len(pulls), func(i int) error {
+		number := pulls[i].GetNumber()
+		reviewOpts := github.ListOptions{PerPage: 100}
+		for {
+			reviews, resp, err := s.client.PullRequests.ListReviews(ctx, ref.Owner, ref.Name, number, &reviewOpts)
+			if err != nil {
+				return err
+			}
+			for _, r := range reviews {
+				if r.SubmittedAt == nil || !inWindow(*r.SubmittedAt, since, until) {
+					continue
+				}
+				eventsByPull[i] = append(eventsByPull[i], RepoEvent{ID: "review:" + strconv.FormatInt(r.GetID(), 10), Handle: r.GetUser().GetLogin(),
+					Repo: ref.String(), Type: "PullRequestReviewEvent", Action: "submitted", Number: number,
+					ReviewState: strings.ToLower(r.GetState()), Source: SourceReviews, CreatedAt: *r.SubmittedAt})
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			reviewOpts.Page = resp.NextPage
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var events []RepoEvent
+	for _, pullEvents := range eventsByPull {
+		events = append(events, pullEvents...)
+	}
+	return events, nil
+}
@@ -0,0 +1,112 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFetchRepoActivityRebuildsNoBranches backfills a repo whose branches would be listed if they were
+// read, and checks that only commits come back and that no branch or commit lookups are made
+func TestFetchRepoActivityRebuildsNoBranches(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/repos/o/r/commits"):
+			fmt.Fprint(w, `[{"sha":"abc","author":{"login":"alice"},"commit":{"committer":{"date":"2018-02-03T10:00:00Z"}}}]`)
+		case strings.HasSuffix(r.URL.Path, "/search/issues"):
+			fmt.Fprint(w, `{"total_count":0,"items":[]}`)
+		case strings.HasSuffix(r.URL.Path, "/repos/o/r/branches"):
+			fmt.Fprint(w, `[{"name":"topic","commit":{"sha":"def"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	events, err := f.FetchRepoActivity(context.Background(), "o/r", since, since.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != "PushEvent" || events[0].Handle != "alice" || events[0].Source != SourceCommits {
+		t.Errorf("events %+v, want the one PushEvent of alice", events)
+	}
+	for _, path := range paths {
+		if strings.Contains(path, "/branches") || strings.Contains(path, "/commits/") {
+			t.Errorf("backfill requested %s", path)
+		}
+	}
+}
+
+func TestFetchCommitActivityGroupsPushes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"sha":"1","committer":{"login":"alice"},"commit":{"committer":{"date":"2018-02-03T18:00:00Z"}}},
+			{"sha":"2","author":{"login":"alice"},"commit":{"committer":{"date":"2018-02-03T09:00:00Z"}}},
+			{"sha":"3","committer":{"login":"alice"},"commit":{"committer":{"date":"2018-02-02T09:00:00Z"}}},
+			{"sha":"4","author":{"login":"bob"},"committer":{"login":"web-flow"},"commit":{"committer":{"date":"2018-02-03T12:00:00Z"}}},
+			{"sha":"5","commit":{"author":{"name":"Carol"},"committer":{"date":"2018-02-03T12:00:00Z"}}}]`)
+	}))
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC)
+	events, err := f.(*fetcher).fetchCommitActivity(context.Background(), RepoRef{Host: defaultHost, Owner: "o", Name: "r"}, since, since.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		handle    string
+		commits   int
+		createdAt string
+	}{
+		{"alice", 2, "2018-02-03T18:00:00Z"},
+		{"alice", 1, "2018-02-02T09:00:00Z"},
+		{"bob", 1, "2018-02-03T12:00:00Z"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events %+v, want %d pushes", events, len(want))
+	}
+	for i, e := range events {
+		if e.Type != "PushEvent" || e.Handle != want[i].handle || e.Commits != want[i].commits || e.CreatedAt.Format(time.RFC3339) != want[i].createdAt {
+			t.Errorf("push %d: %+v, want %+v", i, e, want[i])
+		}
+	}
+}
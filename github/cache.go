@@ -0,0 +1,260 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache an on-disk cache of API responses, one json file per request
+type Cache struct {
+	dir string
+}
+
+// CacheStats what a Cache holds
+type CacheStats struct {
+	Dir     string    `json:"dir"`
+	Entries int       `json:"entries"`
+	Bytes   int64     `json:"bytes"`
+	Oldest  time.Time `json:"oldest"`
+	Newest  time.Time `json:"newest"`
+}
+
+// cacheEntry a stored response. the response is kept in its wire format, Vary holds the request headers
+// the response's Vary names, with their values
+type cacheEntry struct {
+	URL          string            `json:"url"`
+	ETag         string            `json:"etag"`
+	LastModified string            `json:"last_modified"`
+	Vary         map[string]string `json:"vary"`
+	StoredAt     time.Time         `json:"stored_at"`
+	Response     []byte            `json:"response"`
+}
+
+const cacheFileSuffix = ".json"
+
+// NewCache returns the cache kept in dir
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Clear removes every cached response and returns how many there were
+func (c *Cache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			return 0, err
+		}
+	}
+	return len(files), nil
+}
+
+// Stats counts the cached responses and their size on disk
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.dir}
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return stats, err
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if stats.Oldest.IsZero() || info.ModTime().Before(stats.Oldest) {
+			stats.Oldest = info.ModTime()
+		}
+		if info.ModTime().After(stats.Newest) {
+			stats.Newest = info.ModTime()
+		}
+	}
+	return stats, nil
+}
+
+func (c *Cache) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*"+cacheFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// key is the file a request is cached in. the Authorization header is part of it, so a token, a pool
+// token or an app installation is never answered with what another one was allowed to read
+func (c *Cache) key(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.URL.String() + "\n" + req.Header.Get("Accept") + "\n" + req.Header.Get("Authorization")))
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+cacheFileSuffix)
+}
+
+// get returns the entry of req, unless a header the response varies by differs from the stored one
+func (c *Cache) get(req *http.Request) (*cacheEntry, bool) {
+	data, err := ioutil.ReadFile(c.key(req))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	for name, value := range entry.Vary {
+		if req.Header.Get(name) != value {
+			return nil, false
+		}
+	}
+	return &entry, true
+}
+
+// varyHeaders returns the request headers resp varies by and their values in req. false for Vary: *,
+// which no stored response can answer
+func varyHeaders(req *http.Request, resp *http.Response) (map[string]string, bool) {
+	vary := make(map[string]string)
+	for _, values := range resp.Header["Vary"] {
+		for _, name := range strings.Split(values, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" {
+				vary[http.CanonicalHeaderKey(name)] = req.Header.Get(name)
+			}
+		}
+	}
+	return vary, true
+}
+
+// put writes the entry to a temporary file first, so parallel runs never read half an entry
+func (c *Cache) put(req *http.Request, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.key(req))
+}
+
+// cacheTransport makes GET requests conditional on the cached ETag or Last-Modified. github does not count
+// 304 Not Modified against the rate limit, the cached response is returned in its place
+type cacheTransport struct {
+	base  http.RoundTripper
+	cache *Cache
+
+	mu          sync.Mutex
+	notModified int
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	entry, cached := t.cache.get(req)
+	if cached {
+		conditional := cloneRequest(req)
+		if entry.ETag != "" {
+			conditional.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			conditional.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+		req = conditional
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached && resp.StatusCode == http.StatusNotModified {
+		stored, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.Response)), req)
+		if err == nil {
+			resp.Body.Close()
+			// the rate limit headers of the 304 are current, the stored ones are not
+			for name, values := range resp.Header {
+				if strings.HasPrefix(name, "X-Ratelimit-") {
+					stored.Header[name] = values
+				}
+			}
+			t.mu.Lock()
+			t.notModified++
+			t.mu.Unlock()
+			return stored, nil
+		}
+	}
+
+	vary, cacheable := varyHeaders(req, resp)
+	if cacheable && resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dump := *resp
+		dump.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dump.ContentLength = int64(len(body))
+		dump.TransferEncoding = nil
+		var wire bytes.Buffer
+		if err := dump.Write(&wire); err == nil {
+			// a response that cannot be stored is still returned
+			t.cache.put(req, &cacheEntry{URL: req.URL.String(), ETag: resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"), Vary: vary, StoredAt: time.Now().UTC(), Response: wire.Bytes()})
+		}
+	}
+	return resp, nil
+}
+
+func (t *cacheTransport) NotModified() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.notModified
+}
@@ -0,0 +1,167 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+)
+
+// etagServer answers with an ETag per token and a 304 to a request that sends it back
+type etagServer struct {
+	mu          sync.Mutex
+	conditional []string
+	notModified int
+}
+
+func (s *etagServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := r.Header.Get("Authorization")
+	etag := `"` + token + `"`
+	s.conditional = append(s.conditional, r.Header.Get("If-None-Match"))
+	w.Header().Set("Vary", "Accept, Authorization")
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	fmt.Fprintf(w, `[{"number":1,"title":%q}]`, token)
+}
+
+func newCacheDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCacheRevalidatesWithETag(t *testing.T) {
+	dir := newCacheDir(t)
+	defer os.RemoveAll(dir)
+	etags := &etagServer{}
+	server := httptest.NewServer(etags)
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "a", APIURL: server.URL + "/api/v3/", CacheDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		pulls, err := f.FetchPullRequests(context.Background(), "o/r")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pulls) != 1 || pulls[0].Title != "Bearer a" {
+			t.Errorf("run %d: pulls %+v, want the cached pull request", i, pulls)
+		}
+	}
+	if len(etags.conditional) != 2 || etags.conditional[0] != "" || etags.conditional[1] != `"Bearer a"` {
+		t.Errorf("If-None-Match %q, want none and then the stored etag", etags.conditional)
+	}
+	if budget := f.Budget(); etags.notModified != 1 || budget.NotModified != 1 {
+		t.Errorf("%d 304s, %d counted as not modified, want 1", etags.notModified, budget.NotModified)
+	}
+	if stats, err := NewCache(dir).Stats(); err != nil || stats.Entries != 1 {
+		t.Errorf("cache stats %+v %v, want one entry", stats, err)
+	}
+}
+
+// TestCacheKeepsTokensApart runs the same request with two tokens against one cache, the second token
+// must not be sent the etag of, or served, the response of the first
+func TestCacheKeepsTokensApart(t *testing.T) {
+	dir := newCacheDir(t)
+	defer os.RemoveAll(dir)
+	etags := &etagServer{}
+	server := httptest.NewServer(etags)
+	defer server.Close()
+
+	for _, token := range []string{"a", "b"} {
+		f, err := NewFetcherWithOptions(context.Background(), Options{Token: token, APIURL: server.URL + "/api/v3/", CacheDir: dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulls, err := f.FetchPullRequests(context.Background(), "o/r")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pulls) != 1 || pulls[0].Title != "Bearer "+token {
+			t.Errorf("token %s: pulls %+v, want its own response", token, pulls)
+		}
+	}
+	if etags.conditional[1] != "" || etags.notModified != 0 {
+		t.Errorf("If-None-Match %q, want the second token to ask unconditionally", etags.conditional)
+	}
+}
+
+func TestCacheHonorsVary(t *testing.T) {
+	dir := newCacheDir(t)
+	defer os.RemoveAll(dir)
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		w.Header().Set("Vary", "X-Github-Otp")
+		w.Header().Set("ETag", `"x"`)
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &cacheTransport{base: http.DefaultTransport, cache: NewCache(dir)}}
+
+	for _, otp := range []string{"1", "2", "2"} {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req.Header.Set("X-GitHub-OTP", otp)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if conditional[0] != "" || conditional[1] != "" || conditional[2] != `"x"` {
+		t.Errorf("If-None-Match %q, want a conditional request only for the same X-GitHub-OTP", conditional)
+	}
+}
+
+func TestWithoutCacheDirNothingIsCached(t *testing.T) {
+	etags := &etagServer{}
+	server := httptest.NewServer(etags)
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "a", APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := f.FetchPullRequests(context.Background(), "o/r"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if etags.conditional[1] != "" || f.Budget().NotModified != 0 {
+		t.Errorf("If-None-Match %q, want unconditional requests without a cache", etags.conditional)
+	}
+}
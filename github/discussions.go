@@ -23,6 +23,7 @@ package github
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/go-github/github"
 )
@@ -33,39 +34,24 @@ func (s *fetcher) FetchTeamDiscussionComments(ctx context.Context, org, teamName
 		return nil, errors.New("context is nil")
 	}
 
-	listOpts := github.ListOptions{PerPage: 30}
-	var teamID int64
-	for {
-		teams, resp, err := s.client.Teams.ListTeams(ctx, org, &listOpts)
-		if err != nil {
-			return nil, err
-		}
-		for _, t := range teams {
-			if t.GetName() == teamName {
-				teamID = t.GetID()
-				break
-			}
-		}
-		if resp.NextPage == 0 {
-			break
-		}
-		listOpts.Page = resp.NextPage
-	}
-	if teamID == 0 {
-		return nil, errors.New("TeamID is missing")
+	teamID, err := s.findTeamID(ctx, org, teamName)
+	if err != nil {
+		return nil, err
 	}
 
 	teamdiscussions, _, err := s.client.Teams.ListDiscussions(ctx, teamID, nil)
 	if err != nil {
 		return nil, err
 	}
-	var discussionComments []DiscussionComment
-	var discussionComment DiscussionComment
-	for _, td := range teamdiscussions {
+	// the comments of each discussion are fetched in parallel and joined in discussion order
+	commentsByDiscussion := make([][]DiscussionComment, len(teamdiscussions))
+	err = s.forEach(len(teamdiscussions), func(i int) error {
+		td := teamdiscussions[i]
 		dcs, _, err := s.client.Teams.ListComments(ctx, teamID, *td.Number, nil)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		var discussionComment DiscussionComment
 		var body string
 		var handle string
 		var reactionTotalCount int
@@ -75,8 +61,7 @@ func (s *fetcher) FetchTeamDiscussionComments(ctx context.Context, org, teamName
 		var reactionConfused int
 		var reactionHeart int
 		var reactionHooray int
-		var time github.Timestamp
-		var createdAt string
+		var createdAt time.Time
 		var reactions github.Reactions
 		for _, dc := range dcs {
 			handle = *dc.Author.Login
@@ -90,11 +75,18 @@ func (s *fetcher) FetchTeamDiscussionComments(ctx context.Context, org, teamName
 				reactionHeart = *dc.Reactions.Heart
 				reactionHooray = *dc.Reactions.Hooray
 			}
-			time = *dc.CreatedAt
-			createdAt = time.Format("2006-01-02")
-			discussionComment = DiscussionComment{Body: body, Handle: handle, CreatedAt: createdAt, ReactionTotalCount: reactionTotalCount, ReactionPlusOne: reactionPlusOne, ReactionMinusOne: reactionMinusOne, ReactionLaugh: reactionLaugh, ReactionConfused: reactionConfused, ReactionHeart: reactionHeart, ReactionHooray: reactionHooray}
-			discussionComments = append(discussionComments, discussionComment)
+			createdAt = dc.CreatedAt.Time
+			discussionComment = DiscussionComment{DiscussionNumber: td.GetNumber(), Number: dc.GetNumber(), Title: td.GetTitle(), Body: body, Handle: handle, CreatedAt: createdAt, ReactionTotalCount: reactionTotalCount, ReactionPlusOne: reactionPlusOne, ReactionMinusOne: reactionMinusOne, ReactionLaugh: reactionLaugh, ReactionConfused: reactionConfused, ReactionHeart: reactionHeart, ReactionHooray: reactionHooray}
+			commentsByDiscussion[i] = append(commentsByDiscussion[i], discussionComment)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var discussionComments []DiscussionComment
+	for _, dcs := range commentsByDiscussion {
+		discussionComments = append(discussionComments, dcs...)
 	}
 
 	return discussionComments, nil
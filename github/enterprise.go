@@ -0,0 +1,116 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EnterpriseURLs returns the API and upload URLs of the GitHub Enterprise host a repository points to,
+// or empty strings for github.com
+func EnterpriseURLs(repository string) (apiURL, uploadURL string) {
+	ref, err := ParseRepoRef(repository)
+	if err != nil || strings.EqualFold(ref.Host, defaultHost) {
+		return "", ""
+	}
+	scheme := "https"
+	if strings.HasPrefix(repository, "http://") {
+		scheme = "http"
+	}
+	return scheme + "://" + ref.Host + "/api/v3/", scheme + "://" + ref.Host + "/api/uploads/"
+}
+
+// enterpriseAPIURL completes a GitHub Enterprise API url with a trailing slash, and with /api/v3/ when it
+// is only a host
+func enterpriseAPIURL(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return apiURL
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/api/v3/"
+	} else if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
+
+// enterpriseUploadURL returns the upload url next to a completed API url, GitHub Enterprise serves uploads
+// at /api/uploads/
+func enterpriseUploadURL(apiURL string) string {
+	if !strings.HasSuffix(apiURL, "/api/v3/") {
+		return apiURL
+	}
+	return strings.TrimSuffix(apiURL, "/api/v3/") + "/api/uploads/"
+}
+
+// newBaseTransport returns the transport API requests go out on. caBundle is a PEM file of extra
+// certificate authorities to trust, proxy a proxy URL to use instead of the HTTPS_PROXY environment
+func newBaseTransport(caBundle, proxy string) (http.RoundTripper, error) {
+	if caBundle == "" && proxy == "" {
+		return http.DefaultTransport, nil
+	}
+
+	// the settings of http.DefaultTransport
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if caBundle != "" {
+		pem, err := ioutil.ReadFile(caBundle)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
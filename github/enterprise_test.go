@@ -0,0 +1,171 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnterpriseURLs(t *testing.T) {
+	tests := []struct {
+		repository string
+		api        string
+		upload     string
+	}{
+		{"owner/repo", "", ""},
+		{"https://github.com/owner/repo", "", ""},
+		{"git@github.com:owner/repo.git", "", ""},
+		{"git@github-work:owner/repo.git", "", ""},
+		{"ssh://git@github-personal/owner/repo.git", "", ""},
+		{"https://github.example.com/owner/repo", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"https://github.example.com/owner/repo/", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"http://github.example.com/owner/repo", "http://github.example.com/api/v3/", "http://github.example.com/api/uploads/"},
+		{"https://github.example.com:8443/owner/repo", "https://github.example.com:8443/api/v3/", "https://github.example.com:8443/api/uploads/"},
+		{"github.example.com:8443/owner/repo", "https://github.example.com:8443/api/v3/", "https://github.example.com:8443/api/uploads/"},
+		{"ssh://git@github.example.com:2222/owner/repo.git", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"git@github.example.com:owner/repo.git", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+	}
+	for _, test := range tests {
+		api, upload := EnterpriseURLs(test.repository)
+		if api != test.api || upload != test.upload {
+			t.Errorf("EnterpriseURLs(%q) = %q, %q, want %q, %q", test.repository, api, upload, test.api, test.upload)
+		}
+	}
+}
+
+func TestEnterpriseClientURLs(t *testing.T) {
+	tests := []struct {
+		apiURL string
+		api    string
+		upload string
+	}{
+		{"https://github.example.com/api/v3/", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"https://github.example.com/api/v3", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"https://github.example.com", "https://github.example.com/api/v3/", "https://github.example.com/api/uploads/"},
+		{"https://github.example.com:8443/", "https://github.example.com:8443/api/v3/", "https://github.example.com:8443/api/uploads/"},
+		{"https://proxy.example.com/github/", "https://proxy.example.com/github/", "https://proxy.example.com/github/"},
+	}
+	for _, test := range tests {
+		f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: test.apiURL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := f.(*fetcher).client
+		if client.BaseURL.String() != test.api || client.UploadURL.String() != test.upload {
+			t.Errorf("api url %q: client urls %s, %s, want %s, %s", test.apiURL, client.BaseURL, client.UploadURL, test.api, test.upload)
+		}
+	}
+}
+
+func TestBaseTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := ioutil.WriteFile(bundle, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := newBaseTransport(bundle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("server certificate not trusted with the ca bundle: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := (&http.Client{Transport: http.DefaultTransport}).Get(server.URL); err == nil {
+		t.Error("server certificate trusted without the ca bundle")
+	}
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(invalid, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newBaseTransport(invalid, ""); err == nil {
+		t.Error("no error for a ca bundle without certificates")
+	}
+	if _, err := newBaseTransport(filepath.Join(dir, "missing.pem"), ""); err == nil {
+		t.Error("no error for a missing ca bundle")
+	}
+}
+
+func TestBaseTransportProxy(t *testing.T) {
+	transport, err := newBaseTransport("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport != http.DefaultTransport {
+		t.Error("without a ca bundle or a proxy the default transport, and its HTTPS_PROXY, is not used")
+	}
+
+	os.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+	defer os.Unsetenv("HTTPS_PROXY")
+	transport, err = newBaseTransport("", "http://flag-proxy.example.com:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", "https://api.github.com/", nil)
+	proxyURL, err := transport.(*http.Transport).Proxy(req)
+	if err != nil || proxyURL == nil || proxyURL.Host != "flag-proxy.example.com:8080" {
+		t.Errorf("proxy %v %v, want --proxy over HTTPS_PROXY", proxyURL, err)
+	}
+
+	if _, err := newBaseTransport("", "://no-scheme"); err == nil {
+		t.Error("no error for an invalid proxy url")
+	}
+}
+
+func TestBaseTransportWithCABundleKeepsEnvironmentProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	transport, err := newBaseTransport(bundle, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// http.ProxyFromEnvironment reads HTTPS_PROXY once per process, so the function is compared instead
+	if reflect.ValueOf(transport.(*http.Transport).Proxy).Pointer() != reflect.ValueOf(http.ProxyFromEnvironment).Pointer() {
+		t.Error("without --proxy the transport does not use HTTPS_PROXY")
+	}
+}
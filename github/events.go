@@ -0,0 +1,113 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// ParsePayload fills the typed fields of e from the json payload of an event of type e.Type.
+// other event types only have their action, if any, read
+func (e *RepoEvent) ParsePayload(payload []byte) error {
+
+	if len(payload) == 0 {
+		return nil
+	}
+	eventType := e.Type
+	raw := json.RawMessage(payload)
+	parsed, err := (&github.Event{Type: &eventType, RawPayload: &raw}).ParsePayload()
+	if err != nil {
+		return err
+	}
+
+	switch p := parsed.(type) {
+	case *github.PushEvent:
+		e.Ref = p.GetRef()
+		e.Commits = p.GetSize()
+		if e.Commits == 0 {
+			e.Commits = len(p.Commits)
+		}
+	case *github.CreateEvent:
+		e.Ref = p.GetRef()
+		e.RefType = p.GetRefType()
+	case *github.DeleteEvent:
+		e.Ref = p.GetRef()
+		e.RefType = p.GetRefType()
+	case *github.PullRequestEvent:
+		e.Action = p.GetAction()
+		e.Number = p.GetNumber()
+		e.Merged = p.GetPullRequest().GetMerged()
+	case *github.PullRequestReviewEvent:
+		e.Action = p.GetAction()
+		e.Number = p.GetPullRequest().GetNumber()
+		e.ReviewState = strings.ToLower(p.GetReview().GetState())
+	case *github.PullRequestReviewCommentEvent:
+		e.Action = p.GetAction()
+		e.Number = p.GetPullRequest().GetNumber()
+	case *github.IssuesEvent:
+		e.Action = p.GetAction()
+		e.Number = p.GetIssue().GetNumber()
+	case *github.IssueCommentEvent:
+		e.Action = p.GetAction()
+		e.Number = p.GetIssue().GetNumber()
+	case *github.ReleaseEvent:
+		e.Action = p.GetAction()
+		e.Ref = p.GetRelease().GetTagName()
+	default:
+		var other struct {
+			Action string `json:"action"`
+		}
+		if err := json.Unmarshal(payload, &other); err == nil {
+			e.Action = other.Action
+		}
+	}
+	return nil
+}
+
+// SubAction tells events of one type apart: the ref type of a CreateEvent or DeleteEvent (branch, tag or
+// repository), the review state of a PullRequestReviewEvent and the action of other events. a closed
+// pull request that was merged is "merged"
+func (e RepoEvent) SubAction() string {
+	switch e.Type {
+	case "CreateEvent", "DeleteEvent":
+		return e.RefType
+	case "PullRequestEvent":
+		if e.Action == "closed" && e.Merged {
+			return "merged"
+		}
+	case "PullRequestReviewEvent":
+		return e.ReviewState
+	}
+	return e.Action
+}
+
+// MatchesEvent tells whether e is of event, a type like PullRequestEvent or a type and its
+// sub action like PullRequestEvent:merged
+func (e RepoEvent) MatchesEvent(event string) bool {
+	values := strings.SplitN(event, ":", 2)
+	if values[0] != e.Type {
+		return false
+	}
+	return len(values) == 1 || values[1] == e.SubAction()
+}
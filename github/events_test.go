@@ -0,0 +1,97 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import "testing"
+
+func TestParsePayload(t *testing.T) {
+	tests := []struct {
+		eventType string
+		payload   string
+		want      RepoEvent
+		sub       string
+	}{
+		{"PushEvent", `{"ref":"refs/heads/master","size":3,"commits":[{},{},{}]}`,
+			RepoEvent{Ref: "refs/heads/master", Commits: 3}, ""},
+		{"PushEvent", `{"ref":"refs/heads/dev","commits":[{},{}]}`,
+			RepoEvent{Ref: "refs/heads/dev", Commits: 2}, ""},
+		{"CreateEvent", `{"ref":"v1.0","ref_type":"tag"}`,
+			RepoEvent{Ref: "v1.0", RefType: "tag"}, "tag"},
+		{"DeleteEvent", `{"ref":"topic","ref_type":"branch"}`,
+			RepoEvent{Ref: "topic", RefType: "branch"}, "branch"},
+		{"PullRequestEvent", `{"action":"closed","number":7,"pull_request":{"merged":true}}`,
+			RepoEvent{Action: "closed", Number: 7, Merged: true}, "merged"},
+		{"PullRequestEvent", `{"action":"closed","number":8,"pull_request":{"merged":false}}`,
+			RepoEvent{Action: "closed", Number: 8}, "closed"},
+		{"PullRequestReviewEvent", `{"action":"submitted","pull_request":{"number":9},"review":{"state":"APPROVED"}}`,
+			RepoEvent{Action: "submitted", Number: 9, ReviewState: "approved"}, "approved"},
+		{"IssuesEvent", `{"action":"opened","issue":{"number":4}}`,
+			RepoEvent{Action: "opened", Number: 4}, "opened"},
+		{"ReleaseEvent", `{"action":"published","release":{"tag_name":"v2"}}`,
+			RepoEvent{Action: "published", Ref: "v2"}, "published"},
+		{"WatchEvent", `{"action":"started"}`,
+			RepoEvent{Action: "started"}, "started"},
+		{"ForkEvent", ``, RepoEvent{}, ""},
+	}
+	for _, test := range tests {
+		e := RepoEvent{Type: test.eventType}
+		if err := e.ParsePayload([]byte(test.payload)); err != nil {
+			t.Errorf("%s %s: %v", test.eventType, test.payload, err)
+			continue
+		}
+		test.want.Type = test.eventType
+		if e.Ref != test.want.Ref || e.RefType != test.want.RefType || e.Commits != test.want.Commits ||
+			e.Action != test.want.Action || e.Number != test.want.Number || e.Merged != test.want.Merged ||
+			e.ReviewState != test.want.ReviewState {
+			t.Errorf("%s %s: got %+v, want %+v", test.eventType, test.payload, e, test.want)
+		}
+		if sub := e.SubAction(); sub != test.sub {
+			t.Errorf("%s %s: SubAction() = %q, want %q", test.eventType, test.payload, sub, test.sub)
+		}
+	}
+}
+
+func TestParsePayloadMalformed(t *testing.T) {
+	e := RepoEvent{Type: "PushEvent"}
+	if err := e.ParsePayload([]byte(`{"ref":`)); err == nil {
+		t.Error("no error for a truncated payload")
+	}
+}
+
+func TestMatchesEvent(t *testing.T) {
+	merged := RepoEvent{Type: "PullRequestEvent", Action: "closed", Merged: true}
+	tests := []struct {
+		event string
+		want  bool
+	}{
+		{"PullRequestEvent", true},
+		{"PullRequestEvent:merged", true},
+		{"PullRequestEvent:closed", false},
+		{"PullRequestEvent:", false},
+		{"PushEvent", false},
+		{"PushEvent:merged", false},
+	}
+	for _, test := range tests {
+		if got := merged.MatchesEvent(test.event); got != test.want {
+			t.Errorf("MatchesEvent(%q) = %v, want %v", test.event, got, test.want)
+		}
+	}
+}
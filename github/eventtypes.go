@@ -0,0 +1,75 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"errors"
+	"strings"
+)
+
+// EventType a github event type and the short name it goes by in --types and chart lines
+type EventType struct {
+	Name        string
+	Short       string
+	Description string
+}
+
+// EventTypes the registry of github event types, in the order reports list them
+var EventTypes = []EventType{
+	{"PushEvent", "push", "commits pushed to a branch"},
+	{"CreateEvent", "create", "branch, tag or repository created"},
+	{"DeleteEvent", "delete", "branch or tag deleted"},
+	{"PullRequestEvent", "pullrequest", "pull request opened, closed, merged, reopened, ..."},
+	{"PullRequestReviewEvent", "pullrequestreview", "pull request review submitted"},
+	{"PullRequestReviewCommentEvent", "pullrequestreviewcomment", "comment on a pull request diff"},
+	{"IssuesEvent", "issues", "issue opened, closed, reopened, ..."},
+	{"IssueCommentEvent", "issuecomment", "comment on an issue or pull request"},
+	{"CommitCommentEvent", "commitcomment", "comment on a commit"},
+	{"ReleaseEvent", "release", "release published"},
+	{"GollumEvent", "gollum", "wiki page created or updated"},
+	{"ForkEvent", "fork", "repository forked"},
+	{"WatchEvent", "watch", "repository starred"},
+	{"MemberEvent", "member", "collaborator added"},
+	{"PublicEvent", "public", "repository made public"},
+}
+
+// DefaultEventTypes the types charted when --types is not given and no event occurs, so an empty window
+// still gets its zero lines
+var DefaultEventTypes = []string{"PushEvent", "CreateEvent", "PullRequestEvent", "IssuesEvent"}
+
+// LookupEventType finds an event type of types by its name or short name, in any case
+func LookupEventType(types []EventType, name string) (EventType, error) {
+	for _, t := range types {
+		if strings.EqualFold(t.Name, name) || strings.EqualFold(t.Short, name) {
+			return t, nil
+		}
+	}
+	return EventType{}, errors.New("unknown event type " + name)
+}
+
+// EventTypeOf returns the type of types called name. types missing from it get their name without the
+// Event suffix, lowercased, as short name
+func EventTypeOf(types []EventType, name string) EventType {
+	if t, err := LookupEventType(types, name); err == nil {
+		return t
+	}
+	return EventType{Name: name, Short: strings.ToLower(strings.TrimSuffix(name, "Event"))}
+}
@@ -22,76 +22,236 @@ package github
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
 )
 
-//NewFetcher public function to create client for interfacing with github.com API
+// NewFetcher public function to create client for interfacing with github.com API
 func NewFetcher(ctx context.Context, token string) Fetcher {
-	if token == "" {
-		return &fetcher{
-			client: github.NewClient(nil),
+	// without enterprise urls, a ca bundle or a proxy there is nothing that can fail
+	fetcher, _ := NewFetcherWithOptions(ctx, Options{Token: token})
+	return fetcher
+}
+
+// Options configure a Fetcher. Threads bounds how many API requests run at once (default: 1),
+// MaxRequests how many API requests it makes in total (default: no limit).
+// responses are cached in CacheDir, unless it is empty.
+// APIURL and UploadURL point the client at a GitHub Enterprise server, CABundle and Proxy are for corporate networks.
+// Tokens is a pool of tokens, the next one is used when one runs out of requests. Token is a pool of one.
+// with an AppID the client authenticates as installation InstallationID of that GitHub App instead.
+// AppTokenURL overrides where installation tokens are created
+type Options struct {
+	Token          string
+	Tokens         []string
+	AppID          int64
+	AppPrivateKey  []byte
+	InstallationID int64
+	AppTokenURL    string
+	Threads        int
+	MaxRequests    int
+	CacheDir       string
+	APIURL         string
+	UploadURL      string
+	CABundle       string
+	Proxy          string
+}
+
+// NewFetcherWithOptions public function to create a configured client for interfacing with github.com
+// or GitHub Enterprise API
+func NewFetcherWithOptions(ctx context.Context, opts Options) (Fetcher, error) {
+	base, err := newBaseTransport(opts.CABundle, opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	// token requests go straight to the network, skipping the cache and the request budget
+	tokenTransport := base
+	var cache *cacheTransport
+	if opts.CacheDir != "" {
+		cache = &cacheTransport{base: base, cache: NewCache(opts.CacheDir)}
+		base = cache
+	}
+	threads := opts.Threads
+	if threads < 1 {
+		threads = 1
+	}
+	transport := &rateLimitTransport{base: base, maxRequests: opts.MaxRequests, slots: make(chan struct{}, threads)}
+	httpClient := &http.Client{Transport: transport}
+	tokens := opts.Tokens
+	if len(tokens) == 0 && opts.Token != "" {
+		tokens = []string{opts.Token}
+	}
+	apiURL := opts.APIURL
+	if apiURL != "" {
+		apiURL = enterpriseAPIURL(apiURL)
+	}
+	if opts.AppID != 0 {
+		tokenURL := opts.AppTokenURL
+		if tokenURL == "" {
+			tokenURL = installationTokenURL(apiURL, opts.InstallationID)
+		}
+		ts, err := newAppTokenSource(opts.AppID, opts.AppPrivateKey, tokenURL, &http.Client{Transport: tokenTransport})
+		if err != nil {
+			return nil, err
 		}
+		httpClient = oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), ts)
+	} else if len(tokens) > 0 {
+		transport.tokens = newTokenPool(tokens)
 	}
-
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	newClient := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(newClient)
-	return &fetcher{
-		client: client,
+	client := github.NewClient(httpClient)
+	if apiURL != "" {
+		uploadURL := opts.UploadURL
+		if uploadURL == "" {
+			uploadURL = enterpriseUploadURL(apiURL)
+		}
+		if client, err = github.NewEnterpriseClient(apiURL, uploadURL, httpClient); err != nil {
+			return nil, err
+		}
 	}
+	return &fetcher{
+		client:    client,
+		transport: transport,
+		cache:     cache,
+		threads:   opts.Threads,
+	}, nil
 }
 
 type fetcher struct {
-	client *github.Client
+	client    *github.Client
+	transport *rateLimitTransport
+	cache     *cacheTransport
+	threads   int
 }
 
-//Fetcher public functions interfacing with github.com API
+// Budget reports the API requests made so far and the rate limit left
+func (s *fetcher) Budget() Budget {
+	budget := s.transport.Budget()
+	if s.cache != nil {
+		budget.NotModified = s.cache.NotModified()
+	}
+	return budget
+}
+
+// Fetcher public functions interfacing with github.com API
 type Fetcher interface {
 	FetchPullRequestComments(ctx context.Context, repositoryURL string) ([]PullComment, error)
+	FetchPullRequestCommentsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullComment, error)
+	FetchPullRequests(ctx context.Context, repositoryURL string) ([]PullRequest, error)
+	FetchPullRequestsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullRequest, error)
+	FetchPullRequestReviews(ctx context.Context, repositoryURL string) ([]PullReview, error)
+	FetchPullRequestReviewsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullReview, error)
+	FetchPullRequestReviewsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]PullReview, error)
+	FetchReviewRequestsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]ReviewRequest, error)
+	FetchIssueComments(ctx context.Context, repositoryURL string) ([]IssueComment, error)
 	FetchRepoEvents(ctx context.Context, repositoryURL string) ([]RepoEvent, error)
+	FetchRepoEventsSince(ctx context.Context, repositoryURL string, sinceID string) ([]RepoEvent, error)
+	FetchRepoActivity(ctx context.Context, repositoryURL string, since, until time.Time) ([]RepoEvent, error)
 	FetchTeamDiscussionComments(ctx context.Context, org, teamName string) ([]DiscussionComment, error)
+	FetchTeamMembers(ctx context.Context, org, teamName string) ([]string, error)
+	ExpandRepos(ctx context.Context, patterns []string, topic string) ([]string, error)
+	Budget() Budget
 }
 
 // PullComment a struct for local, simplified representation of a PullRequestComment
 type PullComment struct {
-	Handle             string
-	ID                 int64
-	Body               string
-	ReactionTotalCount int
-	ReactionPlusOne    int
-	ReactionMinusOne   int
-	ReactionLaugh      int
-	ReactionConfused   int
-	ReactionHeart      int
-	ReactionHooray     int
-	CreatedAt          string
+	Handle             string    `json:"handle"`
+	ID                 int64     `json:"id"`
+	Repo               string    `json:"repo"`
+	Body               string    `json:"body"`
+	ReactionTotalCount int       `json:"reaction_total_count"`
+	ReactionPlusOne    int       `json:"reaction_plusone"`
+	ReactionMinusOne   int       `json:"reaction_minusone"`
+	ReactionLaugh      int       `json:"reaction_laugh"`
+	ReactionConfused   int       `json:"reaction_confused"`
+	ReactionHeart      int       `json:"reaction_heart"`
+	ReactionHooray     int       `json:"reaction_hooray"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// PullRequest a struct for local, simplified representation of a PullRequest.
+// ClosedAt and MergedAt are zero while the pull request is open or was closed without merging
+type PullRequest struct {
+	Handle    string    `json:"handle"`
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+	ClosedAt  time.Time `json:"closed_at"`
+	MergedAt  time.Time `json:"merged_at"`
+}
+
+// ReviewRequest a struct for local, simplified representation of a review_requested issue event
+type ReviewRequest struct {
+	Reviewer    string    `json:"reviewer"`
+	PullNumber  int       `json:"pull_number"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// PullReview a struct for local, simplified representation of a PullRequestReview
+type PullReview struct {
+	Handle      string    `json:"handle"`
+	ID          int64     `json:"id"`
+	PullNumber  int       `json:"pull_number"`
+	State       string    `json:"state"`
+	Body        string    `json:"body"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// IssueComment a struct for local, simplified representation of an IssueComment.
+// Surface is either SurfacePullRequest or SurfaceIssue
+type IssueComment struct {
+	Handle             string    `json:"handle"`
+	ID                 int64     `json:"id"`
+	Number             int       `json:"number"`
+	Surface            string    `json:"surface"`
+	Body               string    `json:"body"`
+	ReactionTotalCount int       `json:"reaction_total_count"`
+	ReactionPlusOne    int       `json:"reaction_plusone"`
+	ReactionMinusOne   int       `json:"reaction_minusone"`
+	ReactionLaugh      int       `json:"reaction_laugh"`
+	ReactionConfused   int       `json:"reaction_confused"`
+	ReactionHeart      int       `json:"reaction_heart"`
+	ReactionHooray     int       `json:"reaction_hooray"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
-// RepoEvent a struct for local, simplified representation of an RepoEvent for a repository
+// RepoEvent a struct for local, simplified representation of an RepoEvent for a repository.
+// the typed fields are decoded from the payload of the event types that carry them
 type RepoEvent struct {
-	Handle    string
-	Repo      string
-	Type      string
-	Payload   string
-	CreatedAt string
+	ID          string    `json:"id"`
+	Handle      string    `json:"handle"`
+	Repo        string    `json:"repo"`
+	Type        string    `json:"type"`
+	Action      string    `json:"action,omitempty"`
+	Ref         string    `json:"ref,omitempty"`
+	RefType     string    `json:"ref_type,omitempty"`
+	Commits     int       `json:"commits,omitempty"`
+	Files       int       `json:"files,omitempty"`
+	Number      int       `json:"number,omitempty"`
+	Merged      bool      `json:"merged,omitempty"`
+	ReviewState string    `json:"review_state,omitempty"`
+	CoAuthors   []string  `json:"co_authors,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
-// DiscussionComment a struct for local, simplified representation of a DiscussionComment
+// DiscussionComment a struct for local, simplified representation of a DiscussionComment.
+// the api gives team discussion comments no numeric id, they are known by DiscussionNumber and Number
 type DiscussionComment struct {
-	Handle             string
-	ID                 int64
-	Title              string
-	Body               string
-	ReactionTotalCount int
-	ReactionPlusOne    int
-	ReactionMinusOne   int
-	ReactionLaugh      int
-	ReactionConfused   int
-	ReactionHeart      int
-	ReactionHooray     int
-	CreatedAt          string
+	Handle             string    `json:"handle"`
+	ID                 int64     `json:"-"`
+	DiscussionNumber   int       `json:"discussion_number"`
+	Number             int       `json:"number"`
+	Title              string    `json:"title"`
+	Body               string    `json:"body"`
+	ReactionTotalCount int       `json:"reaction_total_count"`
+	ReactionPlusOne    int       `json:"reaction_plusone"`
+	ReactionMinusOne   int       `json:"reaction_minusone"`
+	ReactionLaugh      int       `json:"reaction_laugh"`
+	ReactionConfused   int       `json:"reaction_confused"`
+	ReactionHeart      int       `json:"reaction_heart"`
+	ReactionHooray     int       `json:"reaction_hooray"`
+	CreatedAt          time.Time `json:"created_at"`
 }
@@ -0,0 +1,58 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	"github.com/google/go-github/github"
+)
+
+// SourceGHArchive events imported from GH Archive (https://www.gharchive.org) dumps
+const SourceGHArchive = "gharchive"
+
+// ReadArchive decodes a gzipped GH Archive hourly dump, one event per line in the format of the events
+// api (dumps since 2015), and calls fn with every event. events whose payload cannot be decoded are
+// passed on without their typed fields
+func ReadArchive(r io.Reader, fn func(RepoEvent) error) error {
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	decoder := json.NewDecoder(gz)
+	for {
+		var e github.Event
+		if err := decoder.Decode(&e); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		event, _ := newRepoEvent(&e, SourceGHArchive)
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
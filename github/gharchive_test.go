@@ -0,0 +1,75 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadArchive(t *testing.T) {
+	f, err := os.Open("testdata/2018-01-01-15.json.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []RepoEvent
+	if err := ReadArchive(f, func(e RepoEvent) error {
+		events = append(events, e)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := []RepoEvent{
+		{ID: "7044401123", Handle: "Alice", Repo: "acme/api", Type: "PushEvent", Ref: "refs/heads/master", Commits: 2},
+		{ID: "7044401124", Handle: "bob", Repo: "acme/web", Type: "IssuesEvent", Action: "opened", Number: 7},
+		{ID: "7044401125", Handle: "mallory", Repo: "acme/api", Type: "WatchEvent", Action: "started"},
+		{ID: "7044401126", Handle: "alice", Repo: "other/tool", Type: "CreateEvent", Ref: "v1", RefType: "tag"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("read %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if e.Source != SourceGHArchive {
+			t.Errorf("event %s source %q, want %q", e.ID, e.Source, SourceGHArchive)
+		}
+		if e.CreatedAt.IsZero() {
+			t.Errorf("event %s has no created_at", e.ID)
+		}
+		w := want[i]
+		if e.ID != w.ID || e.Handle != w.Handle || e.Repo != w.Repo || e.Type != w.Type || e.Action != w.Action ||
+			e.Ref != w.Ref || e.RefType != w.RefType || e.Commits != w.Commits || e.Number != w.Number {
+			t.Errorf("event %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestReadArchiveNotGzipped(t *testing.T) {
+	f, err := os.Open("gharchive.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := ReadArchive(f, func(RepoEvent) error { return nil }); err == nil {
+		t.Error("want an error for a file that is not gzipped")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// surfaces a conversation comment can be left on
+const (
+	SurfacePullRequest = "pullrequest"
+	SurfaceIssue       = "issue"
+)
+
+func (s *fetcher) FetchIssueComments(ctx context.Context, repositoryURL string) ([]IssueComment, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo := ref.Owner, ref.Name
+
+	listOpts := github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var issueComments []IssueComment
+	for {
+		comments, resp, err := s.client.Issues.ListComments(ctx, owner, repo, 0, &listOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			reactions := c.GetReactions()
+			issueComment := IssueComment{ID: c.GetID(), Body: c.GetBody(), Handle: c.GetUser().GetLogin(),
+				Number: issueNumber(c.GetIssueURL()), Surface: commentSurface(c.GetHTMLURL()),
+				CreatedAt:          c.GetCreatedAt(),
+				ReactionTotalCount: reactions.GetTotalCount(), ReactionPlusOne: reactions.GetPlusOne(),
+				ReactionMinusOne: reactions.GetMinusOne(), ReactionLaugh: reactions.GetLaugh(),
+				ReactionConfused: reactions.GetConfused(), ReactionHeart: reactions.GetHeart(), ReactionHooray: reactions.GetHooray()}
+			issueComments = append(issueComments, issueComment)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return issueComments, nil
+}
+
+// issueNumber returns the trailing number of an issue api url
+func issueNumber(issueURL string) int {
+	number, err := strconv.Atoi(issueURL[strings.LastIndex(issueURL, "/")+1:])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// commentSurface tells pull request conversation comments apart from plain issue comments.
+// the issues api serves both, only the html url points at /pull/ for the former.
+func commentSurface(htmlURL string) string {
+	if strings.Contains(htmlURL, "/pull/") {
+		return SurfacePullRequest
+	}
+	return SurfaceIssue
+}
@@ -0,0 +1,99 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForEachKeepsOrderAndReturnsLowestError(t *testing.T) {
+	results := make([]int, 20)
+	err := ForEach(4, len(results), func(i int) error {
+		results[i] = i * i
+		if i == 7 || i == 12 {
+			return fmt.Errorf("failed %d", i)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "failed 7" {
+		t.Errorf("err %v, want failed 7", err)
+	}
+	for i, r := range results {
+		if r != i*i {
+			t.Fatalf("result %d is %d", i, r)
+		}
+	}
+}
+
+// TestNestedPoolsShareRequestSlots runs the reviews of several repos at once, each on its own pool, and
+// checks that no more than --threads requests reach the server at the same time
+func TestNestedPoolsShareRequestSlots(t *testing.T) {
+	const threads = 3
+	var mu sync.Mutex
+	inFlight, maxInFlight, requests := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requests++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/", Threads: threads})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pullNumbers := []int{1, 2, 3, 4, 5, 6}
+	err = ForEach(threads, 4, func(i int) error {
+		_, err := f.FetchPullRequestReviewsOf(context.Background(), fmt.Sprintf("o/r%d", i), pullNumbers)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 4*len(pullNumbers) {
+		t.Errorf("%d requests, want %d", requests, 4*len(pullNumbers))
+	}
+	if maxInFlight > threads {
+		t.Errorf("%d requests in flight at once, want at most %d", maxInFlight, threads)
+	}
+}
+
+func TestForEachWithoutWork(t *testing.T) {
+	if err := ForEach(2, 0, func(i int) error { return errors.New("called") }); err != nil {
+		t.Error(err)
+	}
+}
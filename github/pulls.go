@@ -23,30 +23,31 @@ package github
 import (
 	"context"
 	"errors"
-	"net/url"
-	"strings"
+	"fmt"
 	"time"
 
 	"github.com/google/go-github/github"
 )
 
 func (s *fetcher) FetchPullRequestComments(ctx context.Context, repositoryURL string) ([]PullComment, error) {
+	return s.FetchPullRequestCommentsSince(ctx, repositoryURL, time.Time{})
+}
+
+// FetchPullRequestCommentsSince only fetches comments updated at or after since. a zero since fetches all of them
+func (s *fetcher) FetchPullRequestCommentsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullComment, error) {
 
 	if ctx == nil {
 		return nil, errors.New("context is nil")
 	}
 
-	url, err := url.Parse(repositoryURL)
+	ref, err := ParseRepoRef(repositoryURL)
 	if err != nil {
 		return nil, err
 	}
-	values := strings.Split(url.Path, "/")
-	if len(values) < 3 {
-		return nil, errors.New("invalid repository url")
-	}
-	owner, repo := values[1], values[2]
+	owner, repo := ref.Owner, ref.Name
 
 	listOpts := github.PullRequestListCommentsOptions{
+		Since:       since,
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
 
@@ -70,8 +71,7 @@ func (s *fetcher) FetchPullRequestComments(ctx context.Context, repositoryURL st
 		var reactionConfused int
 		var reactionHeart int
 		var reactionHooray int
-		var commentCreatedAt string
-		var time time.Time
+		var commentCreatedAt time.Time
 		for _, prc := range pullRequestComments {
 			id = *prc.ID
 			body = *prc.Body
@@ -83,9 +83,8 @@ func (s *fetcher) FetchPullRequestComments(ctx context.Context, repositoryURL st
 			reactionConfused = *prc.Reactions.Confused
 			reactionHeart = *prc.Reactions.Heart
 			reactionHooray = *prc.Reactions.Hooray
-			time = *prc.CreatedAt
-			commentCreatedAt = time.Format("2006-01-02")
-			pullComment = PullComment{ID: id, Body: body, Handle: handle, CreatedAt: commentCreatedAt,
+			commentCreatedAt = *prc.CreatedAt
+			pullComment = PullComment{ID: id, Repo: owner + "/" + repo, Body: body, Handle: handle, CreatedAt: commentCreatedAt,
 				ReactionTotalCount: reactionTotalCount, ReactionPlusOne: reactionPlusOne,
 				ReactionMinusOne: reactionMinusOne, ReactionLaugh: reactionLaugh,
 				ReactionConfused: reactionConfused, ReactionHeart: reactionHeart, ReactionHooray: reactionHooray}
@@ -100,3 +99,123 @@ func (s *fetcher) FetchPullRequestComments(ctx context.Context, repositoryURL st
 
 	return pullComments, nil
 }
+
+func (s *fetcher) FetchPullRequests(ctx context.Context, repositoryURL string) ([]PullRequest, error) {
+	return s.FetchPullRequestsSince(ctx, repositoryURL, time.Time{})
+}
+
+// FetchPullRequestsSince only fetches the pull requests created at or after since, newest first. a zero
+// since fetches all of them
+func (s *fetcher) FetchPullRequestsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullRequest, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.listPullRequests(ctx, ref, "created", since)
+}
+
+// listPullRequests lists the pull requests of ref newest first by sort, created or updated, and stops at the
+// first one created or updated before since. a zero since lists all of them
+func (s *fetcher) listPullRequests(ctx context.Context, ref RepoRef, sort string, since time.Time) ([]PullRequest, error) {
+
+	listOpts := github.PullRequestListOptions{
+		State:       "all",
+		Sort:        sort,
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var pullRequests []PullRequest
+	for {
+		prs, resp, err := s.client.PullRequests.List(ctx, ref.Owner, ref.Name, &listOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			sortedAt := pr.GetCreatedAt()
+			if sort == "updated" {
+				sortedAt = pr.GetUpdatedAt()
+			}
+			if sortedAt.Before(since) {
+				return pullRequests, nil
+			}
+			pullRequest := PullRequest{Number: pr.GetNumber(), Handle: pr.GetUser().GetLogin(), Title: pr.GetTitle(),
+				State: pr.GetState(), CreatedAt: pr.GetCreatedAt(), ClosedAt: pr.GetClosedAt(), MergedAt: pr.GetMergedAt()}
+			pullRequests = append(pullRequests, pullRequest)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return pullRequests, nil
+}
+
+// reviewRequestedEvent is the subset of an issue event needed to tell when a reviewer was asked to look
+// at a pull request
+type reviewRequestedEvent struct {
+	Event             string       `json:"event"`
+	CreatedAt         time.Time    `json:"created_at"`
+	RequestedReviewer *github.User `json:"requested_reviewer"`
+}
+
+// FetchReviewRequestsOf fetches when reviews of the given pull requests were requested, a request per page
+// of the events of each pull request
+func (s *fetcher) FetchReviewRequestsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]ReviewRequest, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo := ref.Owner, ref.Name
+
+	// the go-github IssueEvent does not carry requested_reviewer yet, so decode the issue events ourselves.
+	// they are fetched for several pull requests at once and joined in pull request order
+	requestsByPull := make([][]ReviewRequest, len(pullNumbers))
+	err = s.forEach(len(pullNumbers), func(i int) error {
+		number := pullNumbers[i]
+		page := 1
+		for {
+			req, err := s.client.NewRequest("GET", fmt.Sprintf("repos/%v/%v/issues/%v/events?per_page=100&page=%v", owner, repo, number, page), nil)
+			if err != nil {
+				return err
+			}
+			var events []reviewRequestedEvent
+			resp, err := s.client.Do(ctx, req, &events)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				if e.Event != "review_requested" || e.RequestedReviewer == nil {
+					continue
+				}
+				reviewRequest := ReviewRequest{PullNumber: number, Reviewer: e.RequestedReviewer.GetLogin(), RequestedAt: e.CreatedAt}
+				requestsByPull[i] = append(requestsByPull[i], reviewRequest)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			page = resp.NextPage
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var reviewRequests []ReviewRequest
+	for _, requests := range requestsByPull {
+		reviewRequests = append(reviewRequests, requests...)
+	}
+
+	return reviewRequests, nil
+}
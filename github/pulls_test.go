@@ -0,0 +1,87 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetchPullRequestsSinceStopsAtOlderPulls(t *testing.T) {
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		if r.URL.Query().Get("sort") != "created" || r.URL.Query().Get("direction") != "desc" {
+			t.Errorf("pulls listed with %s, want sort=created and direction=desc", r.URL.RawQuery)
+		}
+		w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `[{"number":3,"created_at":"2018-02-03T00:00:00Z"},{"number":2,"created_at":"2018-01-30T00:00:00Z"}]`)
+	}))
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulls, err := f.FetchPullRequestsSince(context.Background(), "o/r", time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulls) != 1 || pulls[0].Number != 3 || pages != 1 {
+		t.Errorf("pulls %+v from %d pages, want pull request 3 from one page", pulls, pages)
+	}
+}
+
+func TestFetchReviewRequestsOfOnlyReadsTheGivenPulls(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		fmt.Fprint(w, `[{"event":"labeled","created_at":"2018-02-01T00:00:00Z"},
+			{"event":"review_requested","created_at":"2018-02-02T00:00:00Z","requested_reviewer":{"login":"bob"}},
+			{"event":"review_requested","created_at":"2018-02-02T00:00:00Z","requested_team":{"slug":"core"}}]`)
+	}))
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/", Threads: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, err := f.FetchReviewRequestsOf(context.Background(), "o/r", []int{5, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[0].PullNumber != 5 || requests[1].PullNumber != 8 || requests[0].Reviewer != "bob" {
+		t.Errorf("review requests %+v, want bob on 5 and 8", requests)
+	}
+	for _, path := range paths {
+		if path != "/api/v3/repos/o/r/issues/5/events" && path != "/api/v3/repos/o/r/issues/8/events" {
+			t.Errorf("requested %s", path)
+		}
+	}
+}
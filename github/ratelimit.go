@@ -0,0 +1,307 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetries is how often a request is retried after a secondary rate limit
+const maxRetries = 5
+
+// ErrMaxRequests is returned once a run has made Options.MaxRequests API requests
+var ErrMaxRequests = errors.New("reached --max-requests, stopping before the next API request")
+
+// Budget the API requests a run made and the core rate limit github reported last
+type Budget struct {
+	Requests    int           `json:"requests"`
+	NotModified int           `json:"not_modified"`
+	Retries     int           `json:"retries"`
+	Rotations   int           `json:"rotations"`
+	Waited      time.Duration `json:"waited"`
+	Limit       int           `json:"limit"`
+	Remaining   int           `json:"remaining"`
+	Reset       time.Time     `json:"reset"`
+}
+
+func (b Budget) String() string {
+	s := fmt.Sprintf("api budget: %d requests", b.Requests)
+	if b.NotModified > 0 {
+		s += fmt.Sprintf(" (%d not modified since cached)", b.NotModified)
+	}
+	if b.Limit > 0 {
+		s += fmt.Sprintf(", %d of %d left until %s", b.Remaining, b.Limit, b.Reset.Local().Format("15:04:05"))
+	}
+	if b.Rotations > 0 {
+		s += fmt.Sprintf(", switched tokens %d times", b.Rotations)
+	}
+	if b.Retries > 0 {
+		s += fmt.Sprintf(", %d retries", b.Retries)
+	}
+	if b.Waited > 0 {
+		s += fmt.Sprintf(", waited %s for rate limits", b.Waited.Round(time.Second))
+	}
+	return s
+}
+
+// rateLimitTransport keeps track of the X-RateLimit-* headers. when the limit is used up it sleeps
+// until the reset instead of failing, and it retries secondary rate limits (403/429) with a
+// jittered backoff, so a long run does not lose the pages it already fetched.
+// with a token pool it authorizes requests itself and switches tokens instead of sleeping.
+// slots holds one entry per request in flight, from sending it until its body is closed, so however
+// the worker pools of a run nest, no more than --threads requests run at once.
+// limits are kept per X-RateLimit-Resource, the search api's 30 a minute must not stand in for the core
+// limit. after is time.After, tests replace it to not sleep
+type rateLimitTransport struct {
+	base        http.RoundTripper
+	maxRequests int
+	tokens      *tokenPool
+	slots       chan struct{}
+	after       func(time.Duration) <-chan time.Time
+
+	mu     sync.Mutex
+	budget Budget
+	limits map[string]rateLimit
+}
+
+// rateLimit the X-RateLimit-* headers of one resource, e.g. core or search
+type rateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.slots == nil {
+		return t.roundTrip(req)
+	}
+	t.slots <- struct{}{}
+	resp, err := t.roundTrip(req)
+	if err != nil {
+		<-t.slots
+		return nil, err
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: func() { <-t.slots }}
+	return resp, nil
+}
+
+// slotBody gives the request slot back once the response body is closed
+type slotBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+func (t *rateLimitTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.count(); err != nil {
+			return nil, err
+		}
+		authorized, token := t.authorize(req)
+		resp, err := t.base.RoundTrip(authorized)
+		if err != nil {
+			return nil, err
+		}
+		exhausted, reset := t.update(resp)
+		rotated := exhausted && t.rotate(token, reset)
+
+		if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+			// go-github refuses to send requests while the last response said no requests are left,
+			// waiting here lets the next request through. after switching tokens the used up limit
+			// no longer applies, so it is not passed on
+			if rotated {
+				for name := range resp.Header {
+					if strings.HasPrefix(name, "X-Ratelimit-") {
+						resp.Header.Del(name)
+					}
+				}
+			} else if exhausted {
+				if err := t.sleep(req, untilReset(reset)); err != nil {
+					return nil, err
+				}
+			}
+			return resp, nil
+		}
+
+		var wait time.Duration
+		switch {
+		case rotated:
+			wait = 0
+		case exhausted:
+			wait = untilReset(reset)
+		case resp.Header.Get("Retry-After") != "" || resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp):
+			wait = backoff(attempt)
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+		default:
+			// a 403 for missing permissions
+			return resp, nil
+		}
+		if attempt == maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		resp.Body.Close()
+		if err := t.sleep(req, wait); err != nil {
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		t.mu.Lock()
+		t.budget.Retries++
+		t.mu.Unlock()
+	}
+}
+
+// authorize returns req with the current token of the pool, or req itself without a pool
+func (t *rateLimitTransport) authorize(req *http.Request) (*http.Request, string) {
+	if t.tokens == nil {
+		return req, ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tokens.authorize(req)
+}
+
+// rotate switches the pool to a token with requests left, once token is used up until reset
+func (t *rateLimitTransport) rotate(token string, reset time.Time) bool {
+	if t.tokens == nil || len(t.tokens.tokens) < 2 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.tokens.rotate(token, reset) {
+		return false
+	}
+	t.budget.Rotations++
+	return true
+}
+
+// count adds a request to the budget, or fails once --max-requests is reached
+func (t *rateLimitTransport) count() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.maxRequests > 0 && t.budget.Requests >= t.maxRequests {
+		return ErrMaxRequests
+	}
+	t.budget.Requests++
+	return nil
+}
+
+// update reads the rate limit headers of resp into the limit of their resource, core when github does
+// not name one. it tells whether no requests of that resource are left and when they are reset
+func (t *rateLimitTransport) update(resp *http.Response) (bool, time.Time) {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return false, time.Time{}
+	}
+	var limit rateLimit
+	limit.Remaining, _ = strconv.Atoi(remaining)
+	limit.Limit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		limit.Reset = time.Unix(reset, 0)
+	}
+	resource := resp.Header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.limits == nil {
+		t.limits = make(map[string]rateLimit)
+	}
+	t.limits[resource] = limit
+	if resource == "core" {
+		t.budget.Limit, t.budget.Remaining, t.budget.Reset = limit.Limit, limit.Remaining, limit.Reset
+	}
+	return limit.Remaining == 0, limit.Reset
+}
+
+// untilReset how long until reset, with a second of slack for clock skew
+func untilReset(reset time.Time) time.Duration {
+	return time.Until(reset) + time.Second
+}
+
+// sleep waits for d unless the request is canceled first
+func (t *rateLimitTransport) sleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	t.budget.Waited += d
+	t.mu.Unlock()
+	after := t.after
+	if after == nil {
+		after = time.After
+	}
+	select {
+	case <-after(d):
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func (t *rateLimitTransport) Budget() Budget {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.budget
+}
+
+// isSecondaryRateLimit tells whether a 403 is github's secondary (abuse) rate limit. the body is
+// put back so go-github can still read the error message
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse")
+}
+
+// backoff doubles from one second with up to 50% jitter, so parallel requests do not retry in lockstep
+func backoff(attempt int) time.Duration {
+	d := time.Second << uint(attempt)
+	return d + time.Duration(rand.Int63n(int64(d/2)))
+}
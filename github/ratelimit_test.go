@@ -0,0 +1,268 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sleepRecorder stands in for time.After, it records the waits and returns at once
+type sleepRecorder struct {
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (r *sleepRecorder) after(d time.Duration) <-chan time.Time {
+	r.mu.Lock()
+	r.waits = append(r.waits, d)
+	r.mu.Unlock()
+	c := make(chan time.Time, 1)
+	c <- time.Now()
+	return c
+}
+
+// scriptedServer answers the requests in turn with responses, and repeats the last one
+func scriptedServer(responses ...func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *[]*http.Request) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := len(requests)
+		requests = append(requests, r)
+		mu.Unlock()
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		responses[i](w, r)
+	}))
+	return server, &requests
+}
+
+func respond(status int, headers map[string]string, body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) *http.Response {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	return resp
+}
+
+func TestRetriesSecondaryRateLimit(t *testing.T) {
+	server, requests := scriptedServer(
+		respond(http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`),
+		respond(http.StatusOK, nil, `[]`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after}
+
+	resp := get(t, &http.Client{Transport: transport}, server.URL)
+	if resp.StatusCode != http.StatusOK || len(*requests) != 2 {
+		t.Fatalf("status %d after %d requests, want 200 after 2", resp.StatusCode, len(*requests))
+	}
+	budget := transport.Budget()
+	if budget.Retries != 1 || budget.Requests != 2 {
+		t.Errorf("budget %+v, want 1 retry of 2 requests", budget)
+	}
+	if len(sleeps.waits) != 1 || sleeps.waits[0] < time.Second || sleeps.waits[0] > 1500*time.Millisecond {
+		t.Errorf("waits %v, want one backoff between 1s and 1.5s", sleeps.waits)
+	}
+}
+
+func TestForbiddenWithoutRateLimitIsNotRetried(t *testing.T) {
+	server, requests := scriptedServer(respond(http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after}
+
+	resp := get(t, &http.Client{Transport: transport}, server.URL)
+	if resp.StatusCode != http.StatusForbidden || len(*requests) != 1 || len(sleeps.waits) != 0 {
+		t.Errorf("status %d after %d requests and waits %v, want one 403 without waiting", resp.StatusCode, len(*requests), sleeps.waits)
+	}
+}
+
+func TestRetriesTooManyRequestsAfterRetryAfter(t *testing.T) {
+	server, requests := scriptedServer(
+		respond(http.StatusTooManyRequests, map[string]string{"Retry-After": "7"}, ``),
+		respond(http.StatusOK, nil, `[]`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after}
+
+	resp := get(t, &http.Client{Transport: transport}, server.URL)
+	if resp.StatusCode != http.StatusOK || len(*requests) != 2 {
+		t.Fatalf("status %d after %d requests, want 200 after 2", resp.StatusCode, len(*requests))
+	}
+	if len(sleeps.waits) != 1 || sleeps.waits[0] != 7*time.Second {
+		t.Errorf("waits %v, want the 7s of Retry-After", sleeps.waits)
+	}
+	if waited := transport.Budget().Waited; waited != 7*time.Second {
+		t.Errorf("budget waited %s, want 7s", waited)
+	}
+}
+
+func TestGivesUpAfterMaxRetries(t *testing.T) {
+	server, requests := scriptedServer(respond(http.StatusTooManyRequests, map[string]string{"Retry-After": "1"}, ``))
+	defer server.Close()
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: (&sleepRecorder{}).after}
+
+	resp := get(t, &http.Client{Transport: transport}, server.URL)
+	if resp.StatusCode != http.StatusTooManyRequests || len(*requests) != maxRetries+1 {
+		t.Errorf("status %d after %d requests, want 429 after %d", resp.StatusCode, len(*requests), maxRetries+1)
+	}
+}
+
+func TestExhaustedTokenRotatesWithoutSleeping(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	exhausted := map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}
+	server, requests := scriptedServer(
+		respond(http.StatusForbidden, exhausted, `{"message":"API rate limit exceeded"}`),
+		respond(http.StatusOK, map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "4999", "X-RateLimit-Reset": reset}, `[]`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after, tokens: newTokenPool([]string{"a", "b"})}
+	client := &http.Client{Transport: transport}
+
+	resp := get(t, client, server.URL)
+	get(t, client, server.URL)
+	if resp.StatusCode != http.StatusOK || len(*requests) != 3 {
+		t.Fatalf("status %d after %d requests, want 200 after 3", resp.StatusCode, len(*requests))
+	}
+	var auth []string
+	for _, r := range *requests {
+		auth = append(auth, r.Header.Get("Authorization"))
+	}
+	if auth[0] != "Bearer a" || auth[1] != "Bearer b" || auth[2] != "Bearer b" {
+		t.Errorf("requests authorized with %v, want a, then b", auth)
+	}
+	if len(sleeps.waits) != 0 || transport.Budget().Rotations != 1 {
+		t.Errorf("waits %v and %d rotations, want no wait and one rotation", sleeps.waits, transport.Budget().Rotations)
+	}
+}
+
+func TestExhaustedWithoutPoolWaitsForReset(t *testing.T) {
+	reset := time.Now().Add(10 * time.Minute)
+	server, _ := scriptedServer(respond(http.StatusOK, map[string]string{"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset": strconv.FormatInt(reset.Unix(), 10)}, `[]`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after}
+
+	get(t, &http.Client{Transport: transport}, server.URL)
+	if len(sleeps.waits) != 1 || sleeps.waits[0] < 9*time.Minute || sleeps.waits[0] > 11*time.Minute {
+		t.Errorf("waits %v, want about the 10 minutes until the reset", sleeps.waits)
+	}
+}
+
+// TestSearchLimitKeepsCoreLimit exhausts the search limit after a core response, the wait is until the
+// search reset and the budget still reports the core limit
+func TestSearchLimitKeepsCoreLimit(t *testing.T) {
+	coreReset := time.Now().Add(time.Hour)
+	searchReset := time.Now().Add(30 * time.Second)
+	server, _ := scriptedServer(
+		respond(http.StatusOK, map[string]string{"X-RateLimit-Resource": "core", "X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "4000",
+			"X-RateLimit-Reset": strconv.FormatInt(coreReset.Unix(), 10)}, `[]`),
+		respond(http.StatusOK, map[string]string{"X-RateLimit-Resource": "search", "X-RateLimit-Limit": "30", "X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset": strconv.FormatInt(searchReset.Unix(), 10)}, `{}`))
+	defer server.Close()
+	sleeps := &sleepRecorder{}
+	transport := &rateLimitTransport{base: http.DefaultTransport, after: sleeps.after}
+	client := &http.Client{Transport: transport}
+
+	get(t, client, server.URL+"/repos/o/r/pulls")
+	get(t, client, server.URL+"/search/issues")
+	budget := transport.Budget()
+	if budget.Limit != 5000 || budget.Remaining != 4000 || budget.Reset.Unix() != coreReset.Unix() {
+		t.Errorf("budget %+v, want the core limit", budget)
+	}
+	if len(sleeps.waits) != 1 || sleeps.waits[0] > time.Minute {
+		t.Errorf("waits %v, want one until the search reset", sleeps.waits)
+	}
+	if search := transport.limits["search"]; search.Limit != 30 || search.Remaining != 0 {
+		t.Errorf("search limit %+v, want 0 of 30", search)
+	}
+}
+
+func TestMaxRequests(t *testing.T) {
+	server, requests := scriptedServer(respond(http.StatusOK, nil, `[]`))
+	defer server.Close()
+	transport := &rateLimitTransport{base: http.DefaultTransport, maxRequests: 2}
+	client := &http.Client{Transport: transport}
+
+	get(t, client, server.URL)
+	get(t, client, server.URL)
+	_, err := client.Get(server.URL)
+	if urlErr, ok := err.(*url.Error); !ok || urlErr.Err != ErrMaxRequests {
+		t.Errorf("third request err %v, want ErrMaxRequests", err)
+	}
+	if len(*requests) != 2 || transport.Budget().Requests != 2 {
+		t.Errorf("%d requests sent, %d counted, want 2", len(*requests), transport.Budget().Requests)
+	}
+}
+
+func TestSlotIsReleasedWhenTheBodyIsClosed(t *testing.T) {
+	server, _ := scriptedServer(respond(http.StatusOK, nil, `[]`))
+	defer server.Close()
+	transport := &rateLimitTransport{base: http.DefaultTransport, slots: make(chan struct{}, 1)}
+	client := &http.Client{Transport: transport}
+
+	first, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		get(t, client, server.URL)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("a second request ran while the body of the first was open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second request never ran after the first body was closed")
+	}
+	if len(transport.slots) != 0 {
+		t.Errorf("%d slots held after all bodies were closed", len(transport.slots))
+	}
+}
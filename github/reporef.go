@@ -0,0 +1,147 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+const defaultHost = "github.com"
+
+// RepoRef a reference to a repository on a github host
+type RepoRef struct {
+	Host  string `json:"host"`
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+// ParseRepoRef reads the ways a repository is written down:
+//
+//	owner/repo
+//	github.example.com/owner/repo
+//	https://github.com/owner/repo, also with .git or a path like /pulls after it
+//	git@github.com:owner/repo.git
+//	ssh://git@github.com/owner/repo.git
+//
+// without a host, or with an ssh host alias without a dot, the repository is on github.com
+func ParseRepoRef(repository string) (RepoRef, error) {
+	ref := RepoRef{Host: defaultHost}
+	s := strings.TrimSpace(repository)
+	var path string
+	switch {
+	case strings.Contains(s, "://"):
+		u, err := url.Parse(s)
+		if err != nil {
+			return ref, err
+		}
+		// the port of an http(s) url is the api's as well, an ssh port is not
+		ref.Host, path = u.Host, u.Path
+		if u.Scheme == "ssh" {
+			ref.Host = sshHost(u.Hostname())
+		}
+	case strings.Contains(s, "@"):
+		// scp-like ssh: git@github.com:owner/repo.git, the colon follows the host
+		hostPath := s[strings.Index(s, "@")+1:]
+		colon := strings.Index(hostPath, ":")
+		slash := strings.Index(hostPath, "/")
+		if colon <= 0 || (slash >= 0 && slash < colon) {
+			return ref, errors.New("invalid repository " + repository + ", use owner/repo, a repository url or git@host:owner/repo")
+		}
+		ref.Host, path = sshHost(hostPath[:colon]), hostPath[colon+1:]
+	case strings.HasPrefix(s, "/") || strings.HasPrefix(s, "."):
+		return ref, errors.New("invalid repository " + repository + ", a directory is not a repository, use owner/repo or a repository url")
+	default:
+		path = s
+		values := strings.Split(strings.Trim(s, "/"), "/")
+		if len(values) >= 3 && strings.Contains(values[0], ".") {
+			ref.Host, path = values[0], strings.Join(values[1:], "/")
+		}
+	}
+	values := strings.Split(strings.Trim(path, "/"), "/")
+	if len(values) < 2 || values[0] == "" || values[1] == "" || ref.Host == "" {
+		return ref, errors.New("invalid repository " + repository + ", use owner/repo or a repository url")
+	}
+	ref.Owner, ref.Name = values[0], strings.TrimSuffix(values[1], ".git")
+	if ref.Host == "api."+defaultHost || ref.Host == "www."+defaultHost {
+		ref.Host = defaultHost
+	}
+	return ref, nil
+}
+
+// sshHost is the host of an ssh remote. a host without a dot is an alias of ~/.ssh/config, like
+// github-work for a second github.com account, and stands for github.com
+func sshHost(host string) string {
+	if !strings.Contains(host, ".") {
+		return defaultHost
+	}
+	return host
+}
+
+// String owner/repo
+func (r RepoRef) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// URL the https url of the repository
+func (r RepoRef) URL() string {
+	return "https://" + r.Host + "/" + r.Owner + "/" + r.Name
+}
+
+// RepoRefFromGitRemote returns the repository the git clone in dir was cloned from, its origin remote
+// or else its first remote
+func RepoRefFromGitRemote(dir string) (RepoRef, error) {
+	remote := "origin"
+	remotes, err := git(dir, "remote")
+	if err != nil {
+		return RepoRef{}, errors.New("no repository given and " + dir + " is not a git clone")
+	}
+	names := strings.Fields(remotes)
+	if len(names) == 0 {
+		return RepoRef{}, errors.New("no repository given and the git clone in " + dir + " has no remote")
+	}
+	if !containsName(names, remote) {
+		remote = names[0]
+	}
+	remoteURL, err := git(dir, "config", "--get", "remote."+remote+".url")
+	if err != nil {
+		return RepoRef{}, err
+	}
+	return ParseRepoRef(remoteURL)
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import "testing"
+
+func TestParseRepoRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want RepoRef
+	}{
+		{"owner/repo", RepoRef{"github.com", "owner", "repo"}},
+		{" owner/repo/ ", RepoRef{"github.com", "owner", "repo"}},
+		{"github.example.com/owner/repo", RepoRef{"github.example.com", "owner", "repo"}},
+		{"https://github.com/owner/repo", RepoRef{"github.com", "owner", "repo"}},
+		{"https://github.com/owner/repo.git", RepoRef{"github.com", "owner", "repo"}},
+		{"https://github.com/owner/repo/pulls", RepoRef{"github.com", "owner", "repo"}},
+		{"https://api.github.com/owner/repo", RepoRef{"github.com", "owner", "repo"}},
+		{"https://github.example.com/owner/repo", RepoRef{"github.example.com", "owner", "repo"}},
+		{"https://github.example.com:8443/owner/repo", RepoRef{"github.example.com:8443", "owner", "repo"}},
+		{"ssh://git@github.example.com:2222/owner/repo.git", RepoRef{"github.example.com", "owner", "repo"}},
+		{"git@github.com:owner/repo.git", RepoRef{"github.com", "owner", "repo"}},
+		{"git@github.example.com:owner/repo", RepoRef{"github.example.com", "owner", "repo"}},
+		{"ssh://git@github.com/owner/repo.git", RepoRef{"github.com", "owner", "repo"}},
+		{"git@github-work:owner/repo.git", RepoRef{"github.com", "owner", "repo"}},
+		{"ssh://git@github-work/owner/repo.git", RepoRef{"github.com", "owner", "repo"}},
+		{"owner/*", RepoRef{"github.com", "owner", "*"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRepoRef(tt.in)
+		if err != nil {
+			t.Errorf("ParseRepoRef(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRepoRef(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRepoRefErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"repo",
+		"owner/",
+		"user:tok@github.com/o/r",
+		"git@github.com/owner/repo",
+		"git@:owner/repo",
+		"/root/module",
+		"./module",
+		"../owner/repo",
+	} {
+		if ref, err := ParseRepoRef(in); err == nil {
+			t.Errorf("ParseRepoRef(%q) = %+v, want an error", in, ref)
+		}
+	}
+}
+
+func TestRepoRefStringAndURL(t *testing.T) {
+	ref := RepoRef{"github.example.com", "owner", "repo"}
+	if ref.String() != "owner/repo" || ref.URL() != "https://github.example.com/owner/repo" {
+		t.Errorf("String %q, URL %q", ref.String(), ref.URL())
+	}
+}
@@ -23,58 +23,55 @@ package github
 import (
 	"context"
 	"errors"
-	"net/url"
+	"net/http"
+	"path"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/google/go-github/github"
 )
 
 func (s *fetcher) FetchRepoEvents(ctx context.Context, repositoryURL string) ([]RepoEvent, error) {
+	return s.FetchRepoEventsSince(ctx, repositoryURL, "")
+}
+
+// FetchRepoEventsSince only fetches events newer than the event with id sinceID. the events api lists
+// the newest events first, so paging stops at the first event that was seen before
+func (s *fetcher) FetchRepoEventsSince(ctx context.Context, repositoryURL string, sinceID string) ([]RepoEvent, error) {
 
 	if ctx == nil {
 		return nil, errors.New("context is nil")
 	}
 
-	if repositoryURL == "" {
-		return nil, errors.New("repositoryURL is nil")
-	}
-	url, err := url.Parse(repositoryURL)
+	ref, err := ParseRepoRef(repositoryURL)
 	if err != nil {
 		return nil, err
 	}
-	values := strings.Split(url.Path, "/")
-	if len(values) < 3 {
-		return nil, errors.New("invalid repository url")
-	}
-	owner, repo := values[1], values[2]
+	owner, repo := ref.Owner, ref.Name
 
 	listOpts := github.ListOptions{PerPage: 30}
 
 	var githubEvents []*github.Event
 	var events []RepoEvent
 	var resp *github.Response
+	seen := false
 	for {
 		githubEvents, resp, err = s.client.Activity.ListRepositoryEvents(ctx, owner, repo, &listOpts)
 		if err != nil {
 			return nil, err
 		}
-		var event RepoEvent
-		var repo string
-		var actor string
-		var eventType string
-		var createdDateAt time.Time
-		var createdAt string
 		for _, e := range githubEvents {
-			actor = *e.Actor.Login
-			repo = *e.Repo.Name
-			eventType = *e.Type
-			createdDateAt = *e.CreatedAt
-			createdAt = createdDateAt.Format("2006-01-02")
-			event = RepoEvent{Handle: actor, Type: eventType, CreatedAt: createdAt, Repo: repo}
+			if sinceID != "" && !eventIDAfter(e.GetID(), sinceID) {
+				seen = true
+				break
+			}
+			event, err := newRepoEvent(e, SourceEvents)
+			if err != nil {
+				return nil, err
+			}
 			events = append(events, event)
 		}
-		if resp.NextPage == 0 {
+		if seen || resp.NextPage == 0 {
 			break
 		}
 
@@ -84,3 +81,122 @@ func (s *fetcher) FetchRepoEvents(ctx context.Context, repositoryURL string) ([]
 
 	return events, nil
 }
+
+// newRepoEvent maps an event of the events api, or of a GH Archive dump, to a RepoEvent. the event is
+// returned along with an error when its payload cannot be decoded
+func newRepoEvent(e *github.Event, source string) (RepoEvent, error) {
+	event := RepoEvent{ID: e.GetID(), Handle: e.GetActor().GetLogin(), Type: e.GetType(), CreatedAt: e.GetCreatedAt(),
+		Repo: e.GetRepo().GetName(), Source: source}
+	if e.RawPayload != nil {
+		if err := event.ParsePayload(*e.RawPayload); err != nil {
+			return event, err
+		}
+	}
+	return event, nil
+}
+
+// eventIDAfter tells whether event id was created after event since. ids are increasing numbers
+func eventIDAfter(id, since string) bool {
+	if len(id) != len(since) {
+		return len(id) > len(since)
+	}
+	return id > since
+}
+
+// ExpandRepos turns repository patterns into repository urls. a pattern is a repository url or owner/repo,
+// where the repo may be a glob (org/*, org/service-*). globs are matched against the repos the
+// Repositories API lists for the owner. with a topic only repos tagged with it are kept
+func (s *fetcher) ExpandRepos(ctx context.Context, patterns []string, topic string) ([]string, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	var repositoryURLs []string
+	seen := map[string]bool{}
+	add := func(repositoryURL string) {
+		if !seen[repositoryURL] {
+			seen[repositoryURL] = true
+			repositoryURLs = append(repositoryURLs, repositoryURL)
+		}
+	}
+	for _, pattern := range patterns {
+		ref, err := ParseRepoRef(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.ContainsAny(ref.Name, "*?[") && topic == "" {
+			add(pattern)
+			continue
+		}
+		repos, err := s.listOwnerRepos(ctx, ref.Owner)
+		if err != nil {
+			return nil, err
+		}
+		var matches []*github.Repository
+		for _, r := range repos {
+			if ok, _ := path.Match(ref.Name, r.GetName()); !ok {
+				continue
+			}
+			if topic != "" && !containsTopic(r.Topics, topic) {
+				continue
+			}
+			matches = append(matches, r)
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].GetName() < matches[j].GetName() })
+		for _, r := range matches {
+			add(r.GetHTMLURL())
+		}
+	}
+	if len(repositoryURLs) == 0 {
+		return nil, errors.New("no repository matches " + strings.Join(patterns, ", "))
+	}
+	return repositoryURLs, nil
+}
+
+// listOwnerRepos lists the repos of an organization, or of a user when owner is no organization
+func (s *fetcher) listOwnerRepos(ctx context.Context, owner string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	orgOpts := github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := s.client.Repositories.ListByOrg(ctx, owner, &orgOpts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return s.listUserRepos(ctx, owner)
+			}
+			return nil, err
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOpts.Page = resp.NextPage
+	}
+	return repos, nil
+}
+
+func (s *fetcher) listUserRepos(ctx context.Context, user string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	userOpts := github.RepositoryListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := s.client.Repositories.List(ctx, user, &userOpts)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		userOpts.Page = resp.NextPage
+	}
+	return repos, nil
+}
+
+func containsTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if strings.EqualFold(t, topic) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+func (s *fetcher) FetchPullRequestReviews(ctx context.Context, repositoryURL string) ([]PullReview, error) {
+	return s.FetchPullRequestReviewsSince(ctx, repositoryURL, time.Time{})
+}
+
+// FetchPullRequestReviewsSince only fetches the reviews of the pull requests updated at or after since, a
+// review updates its pull request. a zero since fetches the reviews of every pull request
+func (s *fetcher) FetchPullRequestReviewsSince(ctx context.Context, repositoryURL string, since time.Time) ([]PullReview, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	pullRequests, err := s.listPullRequests(ctx, ref, "updated", since)
+	if err != nil {
+		return nil, err
+	}
+	var pullNumbers []int
+	for _, pr := range pullRequests {
+		pullNumbers = append(pullNumbers, pr.Number)
+	}
+	return s.FetchPullRequestReviewsOf(ctx, repositoryURL, pullNumbers)
+}
+
+// FetchPullRequestReviewsOf only fetches the reviews of the given pull requests, e.g. the ones a report's
+// date range covers, which costs a request per pull request instead of one per pull request of the repo
+func (s *fetcher) FetchPullRequestReviewsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]PullReview, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	ref, err := ParseRepoRef(repositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo := ref.Owner, ref.Name
+
+	// reviews are fetched for several pull requests at once and joined in pull request order
+	reviewsByPull := make([][]PullReview, len(pullNumbers))
+	err = s.forEach(len(pullNumbers), func(i int) error {
+		number := pullNumbers[i]
+		reviewOpts := github.ListOptions{PerPage: 100}
+		for {
+			reviews, resp, err := s.client.PullRequests.ListReviews(ctx, owner, repo, number, &reviewOpts)
+			if err != nil {
+				return err
+			}
+			for _, r := range reviews {
+				// pending reviews have not been submitted yet
+				if r.SubmittedAt == nil {
+					continue
+				}
+				pullReview := PullReview{ID: r.GetID(), PullNumber: number, Handle: r.GetUser().GetLogin(),
+					State: r.GetState(), Body: r.GetBody(), SubmittedAt: *r.SubmittedAt}
+				reviewsByPull[i] = append(reviewsByPull[i], pullReview)
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			reviewOpts.Page = resp.NextPage
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pullReviews []PullReview
+	for _, reviews := range reviewsByPull {
+		pullReviews = append(pullReviews, reviews...)
+	}
+
+	return pullReviews, nil
+}
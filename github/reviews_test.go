@@ -0,0 +1,76 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFetchPullRequestReviewsSinceStopsAtOldPulls lists two pages of pull requests, newest update first, and
+// checks that paging stops at the first one updated before since and only the newer ones have their reviews fetched
+func TestFetchPullRequestReviewsSinceStopsAtOldPulls(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/o/r/pulls", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, "pulls?"+r.URL.RawQuery)
+		mu.Unlock()
+		if r.URL.Query().Get("sort") != "updated" || r.URL.Query().Get("direction") != "desc" {
+			t.Errorf("pulls listed with %s, want sort=updated and direction=desc", r.URL.RawQuery)
+		}
+		w.Header().Set("Link", `<`+"http://"+r.Host+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `[{"number":9,"updated_at":"2018-02-05T00:00:00Z"},{"number":4,"updated_at":"2018-02-02T00:00:00Z"},
+			{"number":7,"updated_at":"2018-01-20T00:00:00Z"},{"number":8,"updated_at":"2018-01-19T00:00:00Z"}]`)
+	})
+	for _, number := range []int{4, 7, 8, 9} {
+		number := number
+		mux.HandleFunc(fmt.Sprintf("/api/v3/repos/o/r/pulls/%d/reviews", number), func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested = append(requested, fmt.Sprintf("reviews %d", number))
+			mu.Unlock()
+			fmt.Fprintf(w, `[{"id":%d,"user":{"login":"bob"},"state":"APPROVED","submitted_at":"2018-02-03T00:00:00Z"}]`, number)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	f, err := NewFetcherWithOptions(context.Background(), Options{Token: "x", APIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews, err := f.FetchPullRequestReviewsSince(context.Background(), "o/r", time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 2 || reviews[0].PullNumber != 9 || reviews[1].PullNumber != 4 {
+		t.Errorf("reviews %+v, want those of pull requests 9 and 4", reviews)
+	}
+	if len(requested) != 3 {
+		t.Errorf("requests %v, want one page of pull requests and the reviews of 9 and 4", requested)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/github"
+)
+
+func (s *fetcher) FetchTeamMembers(ctx context.Context, org, teamName string) ([]string, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	teamID, err := s.findTeamID(ctx, org, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := github.TeamListTeamMembersOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var members []string
+	for {
+		users, resp, err := s.client.Teams.ListTeamMembers(ctx, teamID, &listOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			members = append(members, u.GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+
+	return members, nil
+}
+
+// findTeamID looks up the id of an org's team by its name or slug
+func (s *fetcher) findTeamID(ctx context.Context, org, teamName string) (int64, error) {
+
+	listOpts := github.ListOptions{PerPage: 30}
+	for {
+		teams, resp, err := s.client.Teams.ListTeams(ctx, org, &listOpts)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range teams {
+			if t.GetName() == teamName || t.GetSlug() == teamName {
+				return t.GetID(), nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	return 0, errors.New("TeamID is missing")
+}
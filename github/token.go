@@ -0,0 +1,220 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package github
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// TokenResolver looks up the access tokens for host (e.g. github.com). it returns no tokens when it has
+// none for the host, and names where the tokens came from
+type TokenResolver func(host string) (tokens []string, source string)
+
+// DefaultTokenResolvers the places tokens are looked up, in order: the flag value, GITHUB_ACCESS_TOKEN,
+// the gh cli's hosts.yml, ~/.netrc or ~/.git-credentials and a .env file in the working directory.
+// the flag and GITHUB_ACCESS_TOKEN may hold a comma separated pool of tokens
+func DefaultTokenResolvers(flagValue string) []TokenResolver {
+	return []TokenResolver{
+		func(host string) ([]string, string) { return splitTokens(flagValue), "--token" },
+		func(host string) ([]string, string) {
+			return splitTokens(os.Getenv("GITHUB_ACCESS_TOKEN")), "GITHUB_ACCESS_TOKEN"
+		},
+		GHHostsToken,
+		NetrcToken,
+		GitCredentialsToken,
+		func(host string) ([]string, string) { return DotEnvToken(".env") },
+	}
+}
+
+// ResolveTokens returns the tokens of the first resolver that has any for host
+func ResolveTokens(resolvers []TokenResolver, host string) ([]string, string) {
+	for _, resolve := range resolvers {
+		if tokens, source := resolve(host); len(tokens) > 0 {
+			return tokens, source
+		}
+	}
+	return nil, ""
+}
+
+func splitTokens(value string) []string {
+	var tokens []string
+	for _, token := range strings.Split(value, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// GHHostsToken reads the token the gh cli keeps for host in hosts.yml
+func GHHostsToken(host string) ([]string, string) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		configHome := os.Getenv("XDG_CONFIG_HOME")
+		if configHome == "" {
+			configHome = filepath.Join(homeDir(), ".config")
+		}
+		dir = filepath.Join(configHome, "gh")
+	}
+	fileName := filepath.Join(dir, "hosts.yml")
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, ""
+	}
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return nil, ""
+	}
+	return splitTokens(hosts[host].OAuthToken), fileName
+}
+
+// NetrcToken reads the password of the host (or api.host) machine in $NETRC or ~/.netrc
+func NetrcToken(host string) ([]string, string) {
+	fileName := os.Getenv("NETRC")
+	if fileName == "" {
+		fileName = filepath.Join(homeDir(), ".netrc")
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, ""
+	}
+	fields := strings.Fields(string(data))
+	var machine string
+	for i := 0; i+1 < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			machine = fields[i+1]
+		case "default":
+			machine = ""
+		case "password":
+			if machine == host || machine == "api."+host {
+				return splitTokens(fields[i+1]), fileName
+			}
+		}
+	}
+	return nil, ""
+}
+
+// GitCredentialsToken reads the password stored for host by git's credential store in ~/.git-credentials
+func GitCredentialsToken(host string) ([]string, string) {
+	fileName := filepath.Join(homeDir(), ".git-credentials")
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		u, err := url.Parse(strings.TrimSpace(line))
+		if err != nil || u.User == nil || u.Hostname() != host {
+			continue
+		}
+		if password, ok := u.User.Password(); ok {
+			return splitTokens(password), fileName
+		}
+	}
+	return nil, ""
+}
+
+// DotEnvToken reads GITHUB_ACCESS_TOKEN from a .env file, the file run.sh passes to the commands
+func DotEnvToken(fileName string) ([]string, string) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimPrefix(line, "export ")
+		if strings.HasPrefix(line, "#") || !strings.HasPrefix(line, "GITHUB_ACCESS_TOKEN=") {
+			continue
+		}
+		value := strings.Trim(strings.TrimPrefix(line, "GITHUB_ACCESS_TOKEN="), `"'`)
+		return splitTokens(value), fileName
+	}
+	return nil, ""
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return home
+}
+
+// tokenPool the tokens requests are authorized with. when the current token runs out of requests the
+// next one that has not run out is used, until all of them have
+type tokenPool struct {
+	tokens  []string
+	resets  []time.Time
+	current int
+}
+
+func newTokenPool(tokens []string) *tokenPool {
+	return &tokenPool{tokens: tokens, resets: make([]time.Time, len(tokens))}
+}
+
+// authorize returns a copy of req with the current token and that token
+func (p *tokenPool) authorize(req *http.Request) (*http.Request, string) {
+	token := p.tokens[p.current]
+	authorized := cloneRequest(req)
+	authorized.Header.Set("Authorization", "Bearer "+token)
+	return authorized, token
+}
+
+// rotate marks token as used up until reset and moves on to the next token that has requests left.
+// it tells whether there is one. a token another request already rotated away from counts as rotated
+func (p *tokenPool) rotate(token string, reset time.Time) bool {
+	now := time.Now()
+	if p.tokens[p.current] == token {
+		p.resets[p.current] = reset
+	}
+	for i := 0; i < len(p.tokens); i++ {
+		next := (p.current + i) % len(p.tokens)
+		if !now.Before(p.resets[next]) {
+			p.current = next
+			return true
+		}
+	}
+	return false
+}
+
+// cloneRequest RoundTrippers must not change the request they were given, they change a copy
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header)+2)
+	for name, values := range req.Header {
+		clone.Header[name] = values
+	}
+	return clone
+}
@@ -0,0 +1,224 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gitlog answers fetches from the history of a local git clone, without a token or the network
+package gitlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctava/github-teamwork/github"
+)
+
+// SourceGit events read from the history of a local clone
+const SourceGit = "git"
+
+// event types of a local clone. a CommitEvent's action is commit or merge, every Co-authored-by
+// trailer of a commit adds a CoAuthorEvent for the co-author
+const (
+	CommitEvent   = "CommitEvent"
+	CoAuthorEvent = "CoAuthorEvent"
+)
+
+// EventTypes the event types of a local clone, reports only know them with --git
+var EventTypes = []github.EventType{
+	{Name: CommitEvent, Short: "commit", Description: "commit or merge in a local clone"},
+	{Name: CoAuthorEvent, Short: "coauthor", Description: "co-authored commit in a local clone"},
+}
+
+// errNotInClone is returned for data a git history does not hold
+var errNotInClone = errors.New("not available from a local clone, it only holds commits")
+
+// Fetcher reads the commits of the clone in dir. handles maps lowercase author emails to github
+// handles, other authors are known by the login of their noreply email, or else by their email
+type Fetcher struct {
+	dir     string
+	handles map[string]string
+}
+
+// NewFetcher returns a Fetcher for the clone in dir
+func NewFetcher(dir string, handles map[string]string) *Fetcher {
+	lower := make(map[string]string)
+	for email, handle := range handles {
+		lower[strings.ToLower(email)] = handle
+	}
+	return &Fetcher{dir: dir, handles: lower}
+}
+
+// commit fields are separated by \x1f and commits by \x1e. the files a commit touched follow its message
+const logFormat = "--format=%x1e%H%x1f%P%x1f%an%x1f%ae%x1f%ct%x1f%B%x1f"
+
+var (
+	coAuthorTrailer = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+	noreplyEmail    = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+)
+
+// FetchRepoEvents returns a CommitEvent for every commit of the checked out branch, dated by its committer
+// timestamp, and a CoAuthorEvent for every co-author. events are newest first, like the events api's
+func (f *Fetcher) FetchRepoEvents(ctx context.Context, repositoryURL string) ([]github.RepoEvent, error) {
+
+	if ctx == nil {
+		return nil, errors.New("context is nil")
+	}
+
+	repo := repositoryURL
+	if ref, err := github.ParseRepoRef(repositoryURL); err == nil {
+		repo = ref.String()
+	}
+
+	var stderr bytes.Buffer
+	gitLog := exec.CommandContext(ctx, "git", "-C", f.dir, "log", "--no-color", "--name-only", logFormat)
+	gitLog.Stderr = &stderr
+	out, err := gitLog.Output()
+	if err != nil {
+		return nil, errors.New("git log in " + f.dir + " failed: " + strings.TrimSpace(stderr.String()))
+	}
+
+	var events []github.RepoEvent
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(record, "\x1f")
+		if len(fields) < 7 {
+			continue
+		}
+		sha, parents, email, message, files := fields[0], strings.Fields(fields[1]), fields[3], fields[5], fields[6]
+		seconds, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		committedAt := time.Unix(seconds, 0).UTC()
+
+		commit := github.RepoEvent{ID: sha, Handle: f.handle(email, fields[2]), Repo: repo, Type: CommitEvent,
+			Action: "commit", Ref: sha, Commits: 1, Files: len(strings.Fields(files)), Source: SourceGit, CreatedAt: committedAt}
+		if len(parents) > 1 {
+			commit.Action = "merge"
+		}
+		for _, trailer := range coAuthorTrailer.FindAllStringSubmatch(message, -1) {
+			coAuthor := f.handle(trailer[2], trailer[1])
+			if coAuthor == commit.Handle || containsString(commit.CoAuthors, coAuthor) {
+				continue
+			}
+			commit.CoAuthors = append(commit.CoAuthors, coAuthor)
+		}
+		events = append(events, commit)
+		for _, coAuthor := range commit.CoAuthors {
+			events = append(events, github.RepoEvent{ID: sha + ":" + coAuthor, Handle: coAuthor, Repo: repo, Type: CoAuthorEvent,
+				Action: "coauthored", Ref: sha, Commits: 1, Files: commit.Files, CoAuthors: []string{commit.Handle}, Source: SourceGit, CreatedAt: committedAt})
+		}
+	}
+	return events, nil
+}
+
+// handle is the github handle of an author's email, or the email, or the name of authors without one
+func (f *Fetcher) handle(email, name string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if handle, ok := f.handles[email]; ok {
+		return handle
+	}
+	if match := noreplyEmail.FindStringSubmatch(email); match != nil {
+		return match[1]
+	}
+	if email != "" {
+		return email
+	}
+	return name
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchRepoEventsSince returns all events, a clone has no event ids to start after
+func (f *Fetcher) FetchRepoEventsSince(ctx context.Context, repositoryURL string, sinceID string) ([]github.RepoEvent, error) {
+	return f.FetchRepoEvents(ctx, repositoryURL)
+}
+
+// FetchRepoActivity has nothing to add, the history of a clone is complete
+func (f *Fetcher) FetchRepoActivity(ctx context.Context, repositoryURL string, since, until time.Time) ([]github.RepoEvent, error) {
+	return nil, nil
+}
+
+// ExpandRepos returns the patterns, a clone is one repo
+func (f *Fetcher) ExpandRepos(ctx context.Context, patterns []string, topic string) ([]string, error) {
+	if topic != "" {
+		return nil, errors.New("--topic is " + errNotInClone.Error())
+	}
+	return patterns, nil
+}
+
+// Budget is empty, a clone makes no API requests
+func (f *Fetcher) Budget() github.Budget {
+	return github.Budget{}
+}
+
+func (f *Fetcher) FetchPullRequestComments(ctx context.Context, repositoryURL string) ([]github.PullComment, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequestCommentsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullComment, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequests(ctx context.Context, repositoryURL string) ([]github.PullRequest, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequestsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullRequest, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequestReviews(ctx context.Context, repositoryURL string) ([]github.PullReview, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequestReviewsSince(ctx context.Context, repositoryURL string, since time.Time) ([]github.PullReview, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchPullRequestReviewsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]github.PullReview, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchReviewRequestsOf(ctx context.Context, repositoryURL string, pullNumbers []int) ([]github.ReviewRequest, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchIssueComments(ctx context.Context, repositoryURL string) ([]github.IssueComment, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchTeamDiscussionComments(ctx context.Context, org, teamName string) ([]github.DiscussionComment, error) {
+	return nil, errNotInClone
+}
+
+func (f *Fetcher) FetchTeamMembers(ctx context.Context, org, teamName string) ([]string, error) {
+	return nil, errNotInClone
+}
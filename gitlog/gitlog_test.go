@@ -0,0 +1,125 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gitlog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newTestClone makes a clone with a commit by a roster member, a co-authored commit by a noreply author
+// and a merge by an author known only by email
+func newTestClone(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "gitlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(env []string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, out)
+		}
+	}
+	author := func(name, email, date string) []string {
+		return []string{"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email, "GIT_COMMITTER_NAME=" + name,
+			"GIT_COMMITTER_EMAIL=" + email, "GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+	}
+	write := func(name string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run(nil, "init", "-q")
+	run(nil, "symbolic-ref", "HEAD", "refs/heads/main")
+	write("a.go")
+	write("b.go")
+	run(nil, "add", ".")
+	run(author("Alice", "Alice@Example.com", "2018-01-02T10:00:00Z"), "commit", "-q", "-m", "first")
+	run(nil, "checkout", "-q", "-b", "topic")
+	write("c.go")
+	run(nil, "add", ".")
+	run(author("Bob", "123+bob@users.noreply.github.com", "2018-01-03T10:00:00Z"), "commit", "-q", "-m",
+		"pair\n\nCo-authored-by: Alice <alice@example.com>\nCo-authored-by: Carol <carol@example.com>\nCo-authored-by: Bob <123+bob@users.noreply.github.com>")
+	run(nil, "checkout", "-q", "main")
+	write("d.go")
+	run(nil, "add", ".")
+	run(author("Dave", "dave@example.com", "2018-01-04T10:00:00Z"), "commit", "-q", "-m", "side")
+	run(author("Dave", "dave@example.com", "2018-01-05T10:00:00Z"), "merge", "-q", "--no-ff", "-m", "merge topic", "topic")
+	return dir
+}
+
+func TestFetchRepoEvents(t *testing.T) {
+	dir := newTestClone(t)
+	defer os.RemoveAll(dir)
+
+	f := NewFetcher(dir, map[string]string{"alice@example.com": "alice"})
+	events, err := f.FetchRepoEvents(context.Background(), "local:clone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type row struct {
+		handle, eventType, action string
+		files                     int
+	}
+	want := []row{
+		// git log lists no files for a merge
+		{"dave@example.com", CommitEvent, "merge", 0},
+		{"dave@example.com", CommitEvent, "commit", 1},
+		{"bob", CommitEvent, "commit", 1},
+		{"alice", CoAuthorEvent, "coauthored", 1},
+		{"carol@example.com", CoAuthorEvent, "coauthored", 1},
+		{"alice", CommitEvent, "commit", 2},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("%d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, e := range events {
+		got := row{e.Handle, e.Type, e.Action, e.Files}
+		if got != want[i] || e.Repo != "local:clone" || e.Source != SourceGit {
+			t.Errorf("event %d: %+v, want %+v", i, e, want[i])
+		}
+	}
+	if pair := events[2]; len(pair.CoAuthors) != 2 || pair.CoAuthors[0] != "alice" || pair.CoAuthors[1] != "carol@example.com" {
+		t.Errorf("co-authors %v, want alice and carol@example.com but not the author", pair.CoAuthors)
+	}
+	if merge := events[0]; merge.CreatedAt.Format("2006-01-02") != "2018-01-05" {
+		t.Errorf("merge dated %v", merge.CreatedAt)
+	}
+}
+
+func TestFetchRepoEventsOutsideAClone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := NewFetcher(dir, nil).FetchRepoEvents(context.Background(), "local:dir"); err == nil {
+		t.Error("no error for a directory without a clone")
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright © 2018 Chris Tava <chris1tava@gmail.com>
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package store keeps fetched github data in a local bolt database so that reports can be
+// run offline and later syncs only need to fetch what changed.
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/ctava/github-teamwork/github"
+)
+
+// top level buckets. every data bucket holds one nested bucket per repository or team
+var (
+	pullCommentsBucket       = []byte("pullcomments")
+	repoEventsBucket         = []byte("repoevents")
+	discussionCommentsBucket = []byte("discussioncomments")
+	teamMembersBucket        = []byte("teammembers")
+	marksBucket              = []byte("marks")
+)
+
+// Store a local database of fetched github data
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store at path, creating it if it does not exist
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{pullCommentsBucket, repoEventsBucket, discussionCommentsBucket, teamMembersBucket, marksBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// SavePullComments adds or replaces the pull request comments of repo
+func (s *Store) SavePullComments(repo string, comments []github.PullComment) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(pullCommentsBucket).CreateBucketIfNotExists([]byte(repo))
+		if err != nil {
+			return err
+		}
+		for _, c := range comments {
+			if err := put(b, int64Key(c.ID), c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PullComments returns the stored pull request comments of repo, oldest first
+func (s *Store) PullComments(repo string) ([]github.PullComment, error) {
+	var comments []github.PullComment
+	err := s.each(pullCommentsBucket, repo, func(v []byte) error {
+		var c github.PullComment
+		if err := decode(v, &c); err != nil {
+			return err
+		}
+		comments = append(comments, c)
+		return nil
+	})
+	return comments, err
+}
+
+// SaveRepoEvents adds or replaces the events of repo
+func (s *Store) SaveRepoEvents(repo string, events []github.RepoEvent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(repoEventsBucket).CreateBucketIfNotExists([]byte(repo))
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := put(b, eventKey(e.ID), e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RepoEvents returns the stored events of repo, oldest first
+func (s *Store) RepoEvents(repo string) ([]github.RepoEvent, error) {
+	var events []github.RepoEvent
+	err := s.each(repoEventsBucket, repo, func(v []byte) error {
+		var e github.RepoEvent
+		if err := decode(v, &e); err != nil {
+			return err
+		}
+		events = append(events, e)
+		return nil
+	})
+	return events, err
+}
+
+// SaveDiscussionComments adds or replaces the discussion comments of team (org/team)
+func (s *Store) SaveDiscussionComments(team string, comments []github.DiscussionComment) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(discussionCommentsBucket).CreateBucketIfNotExists([]byte(team))
+		if err != nil {
+			return err
+		}
+		for _, c := range comments {
+			key := append(int64Key(int64(c.DiscussionNumber)), int64Key(int64(c.Number))...)
+			if err := put(b, key, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DiscussionComments returns the stored discussion comments of team (org/team), by discussion and comment number
+func (s *Store) DiscussionComments(team string) ([]github.DiscussionComment, error) {
+	var comments []github.DiscussionComment
+	err := s.each(discussionCommentsBucket, team, func(v []byte) error {
+		var c github.DiscussionComment
+		if err := decode(v, &c); err != nil {
+			return err
+		}
+		comments = append(comments, c)
+		return nil
+	})
+	return comments, err
+}
+
+// SaveTeamMembers replaces the member handles of team (org/team)
+func (s *Store) SaveTeamMembers(team string, members []string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx.Bucket(teamMembersBucket), []byte(team), members)
+	})
+}
+
+// TeamMembers returns the stored member handles of team (org/team)
+func (s *Store) TeamMembers(team string) ([]string, error) {
+	var members []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(teamMembersBucket).Get([]byte(team))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &members)
+	})
+	return members, err
+}
+
+// Repos returns the owner/repo names that pull request comments or events are stored for, sorted
+func (s *Store) Repos() ([]string, error) {
+	seen := map[string]bool{}
+	var repos []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{pullCommentsBucket, repoEventsBucket} {
+			err := tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+				// nested buckets have no value
+				if v == nil && !seen[string(k)] {
+					seen[string(k)] = true
+					repos = append(repos, string(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	sort.Strings(repos)
+	return repos, err
+}
+
+// Mark returns the high-water mark stored under name, or "" if there is none yet
+func (s *Store) Mark(name string) (string, error) {
+	var mark string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		mark = string(tx.Bucket(marksBucket).Get([]byte(name)))
+		return nil
+	})
+	return mark, err
+}
+
+// SetMark stores the high-water mark value under name
+func (s *Store) SetMark(name, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(marksBucket).Put([]byte(name), []byte(value))
+	})
+}
+
+// each calls fn with every value of the nested bucket key of bucket, in key order
+func (s *Store) each(bucket []byte, key string, fn func(v []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket([]byte(key))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return fn(v)
+		})
+	})
+}
+
+// decode reads a stored value. values stored before timestamps were kept have a created_at day,
+// which is read as midnight UTC
+func decode(v []byte, value interface{}) error {
+	err := json.Unmarshal(v, value)
+	if err == nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if json.Unmarshal(v, &fields) != nil {
+		return err
+	}
+	day, ok := fields["created_at"].(string)
+	if !ok || len(day) != len("2006-01-02") {
+		return err
+	}
+	fields["created_at"] = day + "T00:00:00Z"
+	if v, err = json.Marshal(fields); err != nil {
+		return err
+	}
+	return json.Unmarshal(v, value)
+}
+
+func put(b *bolt.Bucket, key []byte, value interface{}) error {
+	v, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, v)
+}
+
+// int64Key big endian keys sort in numeric order
+func int64Key(id int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(id))
+	return key
+}
+
+// eventKey pads event ids so that they sort in numeric order
+func eventKey(id string) []byte {
+	if len(id) < 20 {
+		id = strings.Repeat("0", 20-len(id)) + id
+	}
+	return []byte(id)
+}